@@ -0,0 +1,106 @@
+// Package builtin provides the production Oto templates, embedded
+// so they can be used without downloading them.
+//
+// The templates are copies of those in otohttp/templates, run go
+// generate to update them.
+package builtin
+
+import (
+	"embed"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:generate sh -c "cp ../otohttp/templates/*.plush ./templates/"
+
+//go:embed templates/*.plush
+var files embed.FS
+
+// Prefix is the prefix for template paths that refer to a builtin
+// template, like builtin:go-server.
+const Prefix = "builtin:"
+
+// Template describes a builtin template.
+type Template struct {
+	// Name is the name used to select the template.
+	Name string
+	// Filename is the name of the template file.
+	Filename string
+	// Description describes the output.
+	Description string
+}
+
+// templates are the builtin templates, by name.
+var templates = map[string]Template{
+	"go-server": {
+		Filename:    "server.go.plush",
+		Description: "Go server for github.com/sbward/oto/otohttp",
+	},
+	"go-client": {
+		Filename:    "client.go.plush",
+		Description: "Go client for github.com/sbward/oto/otohttp servers",
+	},
+	"js-client": {
+		Filename:    "client.js.plush",
+		Description: "JavaScript client using fetch",
+	},
+	"ts-client": {
+		Filename:    "client.ts.plush",
+		Description: "TypeScript client and types using fetch",
+	},
+	"swift-client": {
+		Filename:    "client.swift.plush",
+		Description: "Swift client",
+	},
+	"python-client": {
+		Filename:    "client.py.plush",
+		Description: "Python client with dataclasses, using only the standard library",
+	},
+}
+
+// List gets the builtin templates, sorted by name.
+func List() []Template {
+	list := make([]Template, 0, len(templates))
+	for name, t := range templates {
+		t.Name = name
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Lookup gets the builtin template with the name. The name may
+// include Prefix.
+func Lookup(name string) (Template, error) {
+	name = strings.TrimPrefix(name, Prefix)
+	t, ok := templates[name]
+	if !ok {
+		return t, errors.Errorf("no builtin template called %q (use -list-templates to see them)", name)
+	}
+	t.Name = name
+	return t, nil
+}
+
+// Read gets the source of the builtin template with the name.
+// The name may include Prefix.
+func Read(name string) (string, error) {
+	t, err := Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	b, err := files.ReadFile("templates/" + t.Filename)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// IsBuiltin gets whether the template path refers to a builtin
+// template.
+func IsBuiltin(path string) bool {
+	return strings.HasPrefix(path, Prefix)
+}
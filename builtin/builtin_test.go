@@ -0,0 +1,53 @@
+package builtin
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestList(t *testing.T) {
+	is := is.New(t)
+	list := List()
+	is.True(len(list) > 0)
+	for i, template := range list {
+		if i > 0 {
+			is.True(list[i-1].Name < template.Name) // should be sorted
+		}
+		src, err := Read(template.Name)
+		is.NoErr(err)
+		is.True(len(src) > 0)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	is := is.New(t)
+	template, err := Lookup("builtin:go-server")
+	is.NoErr(err)
+	is.Equal(template.Name, "go-server")
+	is.Equal(template.Filename, "server.go.plush")
+	_, err = Lookup("cobol-client")
+	is.True(err != nil)
+	is.True(IsBuiltin("builtin:go-server"))
+	is.True(!IsBuiltin("./server.go.plush"))
+}
+
+// TestUpToDate checks the embedded templates match those in
+// otohttp/templates.
+func TestUpToDate(t *testing.T) {
+	is := is.New(t)
+	originals, err := filepath.Glob("../otohttp/templates/*.plush")
+	is.NoErr(err)
+	is.Equal(len(originals), len(List())) // every template should be builtin
+	for _, original := range originals {
+		want, err := ioutil.ReadFile(original)
+		is.NoErr(err)
+		got, err := ioutil.ReadFile(filepath.Join("templates", filepath.Base(original)))
+		is.NoErr(err)
+		if string(got) != string(want) {
+			t.Errorf("%s is out of date: run go generate ./builtin", filepath.Base(original))
+		}
+	}
+}
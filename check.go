@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+)
+
+// check renders the targets and compares each with its existing output
+// file, printing a unified diff for any that differ, and lists stale
+// outputs.
+// It returns an error if any output is out of date, and writes nothing.
+func check(stdout io.Writer, g generator, targets []target, def parser.Definition) error {
+	var outdated, failed, total int
+	claimed := g.outPaths(targets, def)
+	for _, t := range targets {
+		if t.out == "" {
+			return errors.Errorf("%s: -check needs an output file for every template", t.template)
+		}
+		outputs, stale, err := g.render(t, def, claimed)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s\n", err)
+			failed++
+			continue
+		}
+		total += len(outputs) + len(stale)
+		for _, o := range outputs {
+			existing, err := ioutil.ReadFile(o.path)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "read outfile")
+			}
+			if string(existing) == o.content {
+				continue
+			}
+			outdated++
+			fmt.Fprint(stdout, unifiedDiff(o.path, o.path+" (generated)", string(existing), o.content))
+		}
+		for _, path := range stale {
+			outdated++
+			fmt.Fprintf(stdout, "%s is stale and would be removed\n", path)
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d targets failed", failed, len(targets))
+	}
+	if outdated > 0 {
+		return errors.Errorf("%d of %d outputs are out of date (run oto to regenerate them)", outdated, total)
+	}
+	return nil
+}
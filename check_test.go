@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCheck(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.txt")
+	args := []string{
+		"oto",
+		"-template=./testdata/template.plush",
+		"-out=" + outPath,
+		"./testdata/services/pleasantries",
+	}
+	var buf bytes.Buffer
+	err := run(&buf, args)
+	is.NoErr(err)
+
+	// up to date
+	buf.Reset()
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.NoErr(err)
+	is.Equal(buf.String(), "")
+
+	// out of date
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	edited := strings.Replace(string(b), "GreeterService.Greet\n", "", 1)
+	err = ioutil.WriteFile(outPath, []byte(edited), 0666)
+	is.NoErr(err)
+	buf.Reset()
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "1 of 1 outputs are out of date"))
+	is.True(strings.Contains(buf.String(), "--- "+outPath+"\n"))
+	is.True(strings.Contains(buf.String(), "\n+GreeterService.Greet\n"))
+	b, err = ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), edited) // nothing should be written
+
+	// missing
+	err = os.Remove(outPath)
+	is.NoErr(err)
+	buf.Reset()
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.True(err != nil)
+	_, err = os.Stat(outPath)
+	is.True(os.IsNotExist(err))
+}
+
+func TestCheckNeedsOut(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{
+		"oto",
+		"-check",
+		"-template=./testdata/template.plush",
+		"./testdata/services/pleasantries",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "-check needs an output file"))
+}
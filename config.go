@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/builtin"
+	"github.com/sbward/oto/render"
+	"gopkg.in/yaml.v3"
+)
+
+// configFilenames are the config files that are used, in order,
+// when no -config flag or templates are given.
+var configFilenames = []string{"oto.yaml", "oto.yml", "oto.json"}
+
+// config is an oto project configuration file.
+//
+// Paths are relative to the directory of the config file, which is
+// where post commands are run.
+type config struct {
+	// Packages are the definition package patterns.
+	Packages []string `json:"packages" yaml:"packages"`
+	// Definition is a JSON definition file to use instead of
+	// parsing Packages.
+	Definition string `json:"definition" yaml:"definition"`
+	// Pkg is the explicit package name.
+	Pkg string `json:"pkg" yaml:"pkg"`
+	// Ignore is a list of interfaces to ignore.
+	Ignore []string `json:"ignore" yaml:"ignore"`
+	// Match is a list of interfaces to match.
+	Match []string `json:"match" yaml:"match"`
+	// Params are passed to every template.
+	Params map[string]interface{} `json:"params" yaml:"params"`
+	// Targets are the templates to render.
+	Targets []configTarget `json:"targets" yaml:"targets"`
+	// Engine is the template engine: plush or text.
+	Engine string `json:"engine" yaml:"engine"`
+	// Include are directories searched for partials.
+	Include []string `json:"include" yaml:"include"`
+	// Libraries are partials of shared macros loaded before
+	// every template.
+	Libraries []string `json:"libraries" yaml:"libraries"`
+	// Acronyms are acronyms to add to the defaults, or to remove
+	// when prefixed with -.
+	Acronyms []string `json:"acronyms" yaml:"acronyms"`
+	// Types are type mappings for the type_for helper, by
+	// language.
+	Types map[string]render.TypeMap `json:"types" yaml:"types"`
+	// Format is how outputs are formatted: auto, go or none.
+	Format string `json:"format" yaml:"format"`
+	// Strict makes missing params an error.
+	Strict bool `json:"strict" yaml:"strict"`
+	// Post are shell commands to run after generating every target.
+	Post []string `json:"post" yaml:"post"`
+
+	// dir is the directory of the config file.
+	dir string
+}
+
+// configTarget is a template to render, described in a config file.
+type configTarget struct {
+	// Template is the path to the template.
+	Template string `json:"template" yaml:"template"`
+	// Out is the output file, or a pattern like
+	// out/{{service.Name}}.go for a file per service or object.
+	Out string `json:"out" yaml:"out"`
+	// Params are passed to this template, in addition to the
+	// config Params.
+	Params map[string]interface{} `json:"params" yaml:"params"`
+	// Types are type mappings for this template, in addition to
+	// the config Types.
+	Types map[string]render.TypeMap `json:"types" yaml:"types"`
+}
+
+// loadConfig reads a config file, and resolves its paths against the
+// directory it is in. Files with a .json extension are parsed as
+// JSON, all others as YAML.
+func loadConfig(path string) (config, error) {
+	cfg, err := decodeConfig(path)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.resolve(filepath.Dir(path))
+	return cfg, nil
+}
+
+// decodeConfig reads a config file.
+func decodeConfig(path string) (config, error) {
+	var cfg config
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, errors.Wrap(err, path)
+		}
+		return cfg, nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return cfg, errors.Wrap(err, path)
+	}
+	return cfg, nil
+}
+
+// resolve makes the relative paths in the config relative to dir
+// instead. Libraries are not changed, because they are found in the
+// Include directories.
+func (c *config) resolve(dir string) {
+	c.dir = dir
+	for i, pattern := range c.Packages {
+		c.Packages[i] = resolvePattern(dir, pattern)
+	}
+	c.Definition = resolvePath(dir, c.Definition)
+	for i, include := range c.Include {
+		c.Include[i] = resolvePath(dir, include)
+	}
+	for i, t := range c.Targets {
+		if !builtin.IsBuiltin(t.Template) {
+			c.Targets[i].Template = resolvePath(dir, t.Template)
+		}
+		c.Targets[i].Out = resolvePath(dir, t.Out)
+	}
+}
+
+// resolvePath gets the path relative to dir, if it is relative.
+// Empty paths stay empty.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolvePattern gets the package pattern relative to dir, if it is a
+// relative path like ./services/... rather than an import path.
+func resolvePattern(dir, pattern string) string {
+	if dir == "." || !isRelativePattern(pattern) {
+		return pattern
+	}
+	resolved := filepath.Join(dir, pattern)
+	if filepath.IsAbs(resolved) || isRelativePattern(resolved) {
+		return resolved
+	}
+	// keep it a relative path, so it isn't taken as an import path
+	return "." + string(filepath.Separator) + resolved
+}
+
+// isRelativePattern gets whether the package pattern is a relative
+// path, which go requires to start with . or ..
+func isRelativePattern(pattern string) bool {
+	pattern = filepath.ToSlash(pattern)
+	return pattern == "." || pattern == ".." ||
+		strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../")
+}
+
+// findConfig gets the first of configFilenames that exists in the
+// current directory, or an empty string if there are none.
+func findConfig() string {
+	for _, filename := range configFilenames {
+		if _, err := os.Stat(filename); err == nil {
+			return filename
+		}
+	}
+	return ""
+}
+
+// targets gets the targets described in the config.
+func (c config) targets() []target {
+	targets := make([]target, 0, len(c.Targets))
+	for _, t := range c.Targets {
+		targets = append(targets, target{
+			template: t.Template,
+			out:      t.Out,
+			params:   t.Params,
+			typeMaps: t.Types,
+		})
+	}
+	return targets
+}
+
+// runPost runs the post-generation commands with sh in the config
+// directory, in order, stopping at the first that fails.
+func (c config) runPost(stdout, stderr io.Writer) error {
+	for _, command := range c.Post {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = c.dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "post: %s", command)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLoadConfig(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "oto.yaml")
+	err := ioutil.WriteFile(yamlPath, []byte(`
+packages:
+  - ./definitions
+pkg: generated
+ignore: [Ignorer]
+params:
+  Description: All the things
+  Version: 2
+targets:
+  - template: ./templates/server.go.plush
+    out: ./generated/oto.gen.go
+  - template: ./templates/client.js.plush
+    out: ./generated/oto.gen.js
+    params:
+      Endpoint: https://example.com/oto/
+post:
+  - gofmt -w ./generated/oto.gen.go
+`), 0666)
+	is.NoErr(err)
+	cfg, err := loadConfig(yamlPath)
+	is.NoErr(err)
+	// paths are relative to the config file
+	is.Equal(cfg.Packages, []string{filepath.Join(dir, "definitions")})
+	is.Equal(cfg.Pkg, "generated")
+	is.Equal(cfg.Ignore, []string{"Ignorer"})
+	is.Equal(cfg.Params["Description"], "All the things")
+	is.Equal(cfg.Params["Version"], 2)
+	is.Equal(len(cfg.Targets), 2)
+	is.Equal(cfg.Targets[1].Template, filepath.Join(dir, "templates", "client.js.plush"))
+	is.Equal(cfg.Targets[1].Out, filepath.Join(dir, "generated", "oto.gen.js"))
+	is.Equal(cfg.Targets[1].Params["Endpoint"], "https://example.com/oto/")
+	is.Equal(cfg.Post, []string{"gofmt -w ./generated/oto.gen.go"})
+
+	jsonPath := filepath.Join(dir, "oto.json")
+	err = ioutil.WriteFile(jsonPath, []byte(`{
+		"packages": ["./definitions"],
+		"targets": [
+			{"template": "./templates/server.go.plush", "out": "./oto.gen.go"},
+			{"template": "builtin:ts-client"}
+		]
+	}`), 0666)
+	is.NoErr(err)
+	cfg, err = loadConfig(jsonPath)
+	is.NoErr(err)
+	is.Equal(cfg.Packages, []string{filepath.Join(dir, "definitions")})
+	is.Equal(cfg.targets(), []target{
+		{template: filepath.Join(dir, "templates", "server.go.plush"), out: filepath.Join(dir, "oto.gen.go")},
+		{template: "builtin:ts-client"},
+	})
+}
+
+func TestResolvePattern(t *testing.T) {
+	is := is.New(t)
+	dot := "." + string(filepath.Separator)
+	is.Equal(resolvePattern(".", "./services/..."), "./services/...")
+	is.Equal(resolvePattern("config", "./services/..."), dot+filepath.Join("config", "services", "..."))
+	is.Equal(resolvePattern("config", "./..."), dot+filepath.Join("config", "..."))
+	is.Equal(resolvePattern("config", ".."), ".")
+	is.Equal(resolvePattern("../config", "./..."), filepath.Join("..", "config", "..."))
+	is.Equal(resolvePattern("/config", "./services"), filepath.Join("/config", "services"))
+	is.Equal(resolvePattern("config", "github.com/sbward/oto/services"), "github.com/sbward/oto/services")
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "oto.yaml")
+	err := ioutil.WriteFile(path, []byte(`
+targets:
+  - template: ./server.go.plush
+    output: ./oto.gen.go
+`), 0666)
+	is.NoErr(err)
+	_, err = loadConfig(path)
+	is.True(err != nil) // output is not a field
+	is.True(strings.Contains(err.Error(), "output"))
+}
+
+func TestRunConfig(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`package <%= def.PackageName %>
+// <%= params["Description"] %> <%= params["Endpoint"] %>
+<%= for (service) in def.Services { %><%= service.Name %>
+<% } %>`), 0666)
+	is.NoErr(err)
+	packagePath, err := filepath.Abs("./testdata/services/pleasantries")
+	is.NoErr(err)
+	configPath := filepath.Join(dir, "oto.yaml")
+	err = ioutil.WriteFile(configPath, []byte(`
+packages: [`+packagePath+`]
+pkg: generated
+ignore: [Ignorer, Welcomer]
+params:
+  Description: Services
+  Endpoint: /oto/
+targets:
+  - template: template.plush
+    out: one.txt
+  - template: `+templatePath+`
+    out: ./two.txt
+    params:
+      Endpoint: https://example.com/oto/
+post:
+  - cp one.txt copy.txt
+`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{"oto", "-config", configPath, "-pkg", "overridden"})
+	is.NoErr(err)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "one.txt"))
+	is.NoErr(err)
+	is.True(strings.HasPrefix(string(b), "package overridden\n// Services /oto/\n"))
+	is.True(strings.Contains(string(b), "GreeterService"))
+	is.True(!strings.Contains(string(b), "Welcomer")) // ignored
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "two.txt"))
+	is.NoErr(err)
+	is.True(strings.HasPrefix(string(b), "package overridden\n// Services https://example.com/oto/\n"))
+
+	_, err = ioutil.ReadFile(filepath.Join(dir, "copy.txt"))
+	is.NoErr(err) // post command should have run in the config directory
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(unifiedDiff("a", "b", "same\n", "same\n"), "")
+
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\nthirteen\n"
+	is.Equal(unifiedDiff("a.txt", "b.txt", a, b), `--- a.txt
++++ b.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -10,3 +10,4 @@
+ ten
+ eleven
+ twelve
++thirteen
+`)
+
+	// nearby changes share a hunk
+	a = "one\ntwo\nthree\nfour\nfive\n"
+	b = "1\ntwo\nthree\nfour\n5\n"
+	is.Equal(unifiedDiff("a.txt", "b.txt", a, b), `--- a.txt
++++ b.txt
+@@ -1,5 +1,5 @@
+-one
++1
+ two
+ three
+ four
+-five
++5
+`)
+
+	is.Equal(unifiedDiff("a.txt", "b.txt", "", "new\n"), `--- a.txt
++++ b.txt
+@@ -0,0 +1 @@
++new
+`)
+
+	is.Equal(unifiedDiff("a.txt", "b.txt", "line\n", "line"), `--- a.txt
++++ b.txt
+@@ -1 +1 @@
+-line
++line
+\ No newline at end of file
+`)
+}
+
+func TestLCSDiff(t *testing.T) {
+	is := is.New(t)
+	ops := lcsDiff(strings.SplitAfter("a\nb\nc\n", "\n")[:3], strings.SplitAfter("b\nc\nd\n", "\n")[:3])
+	var kinds []byte
+	for _, op := range ops {
+		kinds = append(kinds, op.kind)
+	}
+	is.Equal(string(kinds), "-  +")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// dump writes the definition to w as JSON or YAML, using the same
+// field names that templates see with json(def).
+func dump(w io.Writer, def parser.Definition, format string) error {
+	b, err := json.MarshalIndent(def, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "marshal definition")
+	}
+	switch format {
+	case "json":
+		b = append(b, '\n')
+		_, err := w.Write(b)
+		return err
+	case "yaml", "yml":
+		// JSON is YAML, so decoding it into a node keeps the
+		// field order
+		var node yaml.Node
+		if err := yaml.Unmarshal(b, &node); err != nil {
+			return errors.Wrap(err, "yaml")
+		}
+		blockStyle(&node)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return errors.Wrap(err, "yaml")
+		}
+		return enc.Close()
+	}
+	return errors.Errorf("unknown dump format %q (use json or yaml)", format)
+}
+
+// blockStyle sets the node, and its children, to use the plain YAML
+// styles instead of the JSON-like styles they were decoded with.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDump(t *testing.T) {
+	is := is.New(t)
+	def := parser.Definition{
+		PackageName: "things",
+		Objects: []parser.Object{{
+			Name: "Thing",
+			Fields: []parser.Field{{
+				Name:     "Enabled",
+				Example:  "true",
+				Metadata: map[string]interface{}{"max": 3},
+			}},
+		}},
+		Imports: map[string]string{"time": "time"},
+	}
+
+	var buf bytes.Buffer
+	err := dump(&buf, def, "json")
+	is.NoErr(err)
+	var fromJSON parser.Definition
+	err = json.Unmarshal(buf.Bytes(), &fromJSON)
+	is.NoErr(err)
+	is.Equal(fromJSON.PackageName, "things")
+	is.Equal(fromJSON.Objects[0].Fields[0].Example, "true")
+	is.Equal(fromJSON.Imports["time"], "time")
+
+	buf.Reset()
+	err = dump(&buf, def, "yaml")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(buf.String(), "schemaVersion: 0\npackageName: things\nservices: null\n"))
+	var fromYAML map[string]interface{}
+	err = yaml.Unmarshal(buf.Bytes(), &fromYAML)
+	is.NoErr(err)
+	field := fromYAML["objects"].([]interface{})[0].(map[string]interface{})["fields"].([]interface{})[0].(map[string]interface{})
+	is.Equal(field["example"], "true") // should stay a string
+	is.Equal(field["metadata"], map[string]interface{}{"max": 3})
+
+	err = dump(&buf, def, "xml")
+	is.True(err != nil)
+}
+
+func TestRunDump(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-dump", "json", "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	var def parser.Definition
+	err = json.Unmarshal(buf.Bytes(), &def)
+	is.NoErr(err)
+	is.Equal(def.PackageName, "pleasantries")
+	is.True(len(def.Services) > 0)
+}
+
+func TestRunDefinition(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-dump", "json", "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	defPath := filepath.Join(dir, "def.json")
+	err = ioutil.WriteFile(defPath, buf.Bytes(), 0666)
+	is.NoErr(err)
+
+	fromSource := filepath.Join(dir, "source.txt")
+	err = run(&buf, []string{"oto", "-template", "./testdata/template.plush", "-out", fromSource, "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	fromDefinition := filepath.Join(dir, "definition.txt")
+	err = run(&buf, []string{"oto", "-template", "./testdata/template.plush", "-out", fromDefinition, "-definition", defPath})
+	is.NoErr(err)
+	want, err := ioutil.ReadFile(fromSource)
+	is.NoErr(err)
+	got, err := ioutil.ReadFile(fromDefinition)
+	is.NoErr(err)
+	is.Equal(string(got), string(want))
+
+	err = ioutil.WriteFile(defPath, []byte(`{"schemaVersion":999}`), 0666)
+	is.NoErr(err)
+	err = run(&buf, []string{"oto", "-template", "./testdata/template.plush", "-definition", defPath})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "schemaVersion 999"))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+	"github.com/sbward/oto/render"
+)
+
+// output is a rendered output.
+type output struct {
+	// path is the output file, or empty for stdout.
+	path    string
+	content string
+}
+
+// outputsSize gets the total size of the outputs.
+func outputsSize(outputs []output) uint64 {
+	var size uint64
+	for _, o := range outputs {
+		size += uint64(len(o.content))
+	}
+	return size
+}
+
+// renderEach renders an output for each service or object, to the path
+// that the target's out pattern gives it, like out/{{service.Name}}.gen.go.
+// The template gets the item as a variable named service or object.
+// It also returns the files matching the pattern that were not rendered,
+// but were generated by oto, which are stale. Paths in claimed are
+// rendered by other targets, so are never stale. See isGenerated.
+func (g generator) renderEach(t target, each, src string, def parser.Definition, options render.Options, claimed map[string]bool) ([]output, []string, error) {
+	var outputs []output
+	rendered := make(map[string]bool)
+	for _, item := range render.Items(def, each) {
+		path, err := options.Path(t.out, item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rendered[filepath.Clean(path)] {
+			return nil, nil, errors.Errorf("%s: more than one %s renders %s", t.out, each, path)
+		}
+		rendered[filepath.Clean(path)] = true
+		itemOptions := options
+		itemOptions.Data = map[string]interface{}{each: item}
+		out, err := g.renderOutput(t, src, def, itemOptions)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "render %s", path)
+		}
+		outputs = append(outputs, output{path: path, content: out})
+	}
+	existing, err := filepath.Glob(render.Glob(t.out))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "find stale outfiles")
+	}
+	var stale []string
+	for _, path := range existing {
+		if rendered[filepath.Clean(path)] || claimed[filepath.Clean(path)] {
+			continue
+		}
+		generated, err := isGenerated(path)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "find stale outfiles")
+		}
+		if generated {
+			stale = append(stale, path)
+		}
+	}
+	return outputs, stale, nil
+}
+
+// outPaths gets the paths that the targets render to, so that no target
+// removes another's output as stale.
+// Patterns that can't be rendered are skipped, since rendering the target
+// reports the error.
+func (g generator) outPaths(targets []target, def parser.Definition) map[string]bool {
+	paths := make(map[string]bool)
+	options := render.Options{Acronyms: g.acronyms}
+	for _, t := range targets {
+		if t.out == "" {
+			continue
+		}
+		each, err := render.Each(t.out)
+		if err != nil {
+			continue
+		}
+		if each == "" {
+			paths[filepath.Clean(t.out)] = true
+			continue
+		}
+		for _, item := range render.Items(def, each) {
+			path, err := options.Path(t.out, item)
+			if err != nil {
+				break
+			}
+			paths[filepath.Clean(path)] = true
+		}
+	}
+	return paths
+}
+
+// generatedHeader marks the files that oto generated, in a comment at the
+// top, like the builtin templates do.
+var generatedHeader = []byte("Code generated by oto; DO NOT EDIT.")
+
+// headerLines is how many lines at the top of a file may have the
+// generatedHeader.
+const headerLines = 5
+
+// isGenerated gets whether the file was generated by oto, so is safe to
+// remove: it has the generatedHeader near the top.
+func isGenerated(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for i := 0; i < headerLines; i++ {
+		line, err := r.ReadSlice('\n')
+		if bytes.Contains(line, generatedHeader) {
+			return true, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return false, err
+		}
+	}
+	return false, nil
+}
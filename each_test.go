@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRunEach(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "service.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= service.Name %>:<%= for (method) in service.Methods { %> <%= method.Name %><% } %> (<%= len(def.Services) %>)`), 0666)
+	is.NoErr(err)
+	outDir := filepath.Join(dir, "out")
+	err = os.Mkdir(outDir, 0777)
+	is.NoErr(err)
+	// a file from a service that was removed
+	err = ioutil.WriteFile(filepath.Join(outDir, "old_service.gen.txt"), []byte("// Code generated by oto; DO NOT EDIT.\nold"), 0666)
+	is.NoErr(err)
+	err = ioutil.WriteFile(filepath.Join(outDir, "README.md"), []byte("not generated"), 0666)
+	is.NoErr(err)
+	out := filepath.Join(outDir, "{{snake_case service.Name}}.gen.txt")
+	args := []string{"oto", "-template", templatePath, "-out", out, "-ignore", "Ignorer", "./testdata/services/pleasantries"}
+
+	var buf bytes.Buffer
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.True(err != nil)
+	is.True(strings.Contains(buf.String(), "old_service.gen.txt is stale and would be removed"))
+	is.True(strings.Contains(err.Error(), "3 of 3 outputs are out of date"))
+
+	err = run(&buf, args)
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(outDir, "greeter_service.gen.txt"))
+	is.NoErr(err)
+	is.Equal(string(b), "GreeterService: GetGreetings Greet (2)")
+	b, err = ioutil.ReadFile(filepath.Join(outDir, "welcomer.gen.txt"))
+	is.NoErr(err)
+	is.Equal(string(b), "Welcomer: Welcome (2)")
+	_, err = os.Stat(filepath.Join(outDir, "old_service.gen.txt"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(outDir, "README.md"))
+	is.NoErr(err)
+
+	buf.Reset()
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.NoErr(err)
+	is.Equal(buf.String(), "")
+}
+
+func TestRunEachHandWritten(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "service.plush")
+	err := ioutil.WriteFile(templatePath, []byte("// Code generated by oto; DO NOT EDIT.\n\npackage out\n\ntype <%= service.Name %> struct{}\n"), 0666)
+	is.NoErr(err)
+	outDir := filepath.Join(dir, "out")
+	err = os.Mkdir(outDir, 0777)
+	is.NoErr(err)
+	// hand written files match the pattern, but are not stale
+	helpers := filepath.Join(outDir, "helpers.go")
+	err = ioutil.WriteFile(helpers, []byte("package out\n\nfunc helper() {}\n"), 0666)
+	is.NoErr(err)
+	old := filepath.Join(outDir, "OldService.go")
+	err = ioutil.WriteFile(old, []byte("// Code generated by oto; DO NOT EDIT.\n\npackage out\n"), 0666)
+	is.NoErr(err)
+	args := []string{"oto", "-template", templatePath, "-out", filepath.Join(outDir, "{{service.Name}}.go"), "-ignore", "Ignorer", "./testdata/services/pleasantries"}
+
+	var buf bytes.Buffer
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.True(err != nil)
+	is.True(strings.Contains(buf.String(), "OldService.go is stale and would be removed"))
+	is.True(!strings.Contains(buf.String(), "helpers.go"))
+
+	err = run(&buf, args)
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(outDir, "Welcomer.go"))
+	is.NoErr(err)
+	_, err = os.Stat(old)
+	is.True(os.IsNotExist(err))
+	b, err := ioutil.ReadFile(helpers)
+	is.NoErr(err)
+	is.Equal(string(b), "package out\n\nfunc helper() {}\n")
+}
+
+func TestRunEachOtherTargets(t *testing.T) {
+	is := is.New(t)
+	outDir := t.TempDir()
+	all := filepath.Join(outDir, "all.gen.go")
+	args := []string{
+		"oto",
+		"-template", "builtin:go-server", "-out", all,
+		"-template", "builtin:go-server", "-out", filepath.Join(outDir, "{{service.Name}}.gen.go"),
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	}
+	var buf bytes.Buffer
+	err := run(&buf, args)
+	is.NoErr(err)
+	// all.gen.go matches the pattern and has the header, but another
+	// target rendered it
+	_, err = os.Stat(all)
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(outDir, "Welcomer.gen.go"))
+	is.NoErr(err)
+
+	buf.Reset()
+	err = run(&buf, append([]string{"oto", "-check"}, args[1:]...))
+	is.NoErr(err)
+	is.Equal(buf.String(), "")
+}
+
+func TestIsGenerated(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	for content, generated := range map[string]bool{
+		"// Code generated by oto; DO NOT EDIT.\n\npackage out": true,
+		"# Code generated by oto; DO NOT EDIT.":                 true,
+		"package out\n\n// Code generated by oto; DO NOT EDIT.": true,
+		"package out\n": false,
+		"":              false,
+		"1\n2\n3\n4\n5\n// Code generated by oto; DO NOT EDIT.": false,
+	} {
+		path := filepath.Join(dir, "file")
+		err := ioutil.WriteFile(path, []byte(content), 0666)
+		is.NoErr(err)
+		ok, err := isGenerated(path)
+		is.NoErr(err)
+		is.Equal(ok, generated) // content
+	}
+}
+
+func TestRunEachObject(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "object.tmpl")
+	err := ioutil.WriteFile(templatePath, []byte(`{{ .object.Name }}{{ range .object.Fields }} {{ .Name }}{{ end }}`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{"oto", "-template", templatePath, "-out", filepath.Join(dir, "{{object.Name}}.txt"), "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "GreetRequest.txt"))
+	is.NoErr(err)
+	is.Equal(string(b), "GreetRequest Names")
+
+	err = run(&buf, []string{"oto", "-template", templatePath, "-out", filepath.Join(dir, "same.txt{{object.Nope}}"), "./testdata/services/pleasantries"})
+	is.True(err != nil)
+}
+
+func TestOutputsSize(t *testing.T) {
+	is := is.New(t)
+	is.Equal(outputsSize([]output{{content: "one"}, {content: "three"}}), uint64(8))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"go/format"
+	"go/scanner"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Formats for the -format flag.
+const (
+	// formatAuto formats Go outputs, detected by their extension.
+	formatAuto = "auto"
+	// formatGo formats every output as Go.
+	formatGo = "go"
+	// formatNone leaves outputs as they are rendered.
+	formatNone = "none"
+)
+
+// shouldFormat gets whether the target's output should be formatted as
+// Go source.
+// In auto mode, the extension of the output file is used, or of the
+// template (without its template extension) when writing to stdout.
+func shouldFormat(mode string, t target) (bool, error) {
+	switch mode {
+	case formatAuto, "":
+		name := t.out
+		if name == "" {
+			name = templateFilename(t.template)
+			if ext := filepath.Ext(name); isTemplateExt(ext) {
+				name = strings.TrimSuffix(name, ext)
+			}
+		}
+		return filepath.Ext(name) == ".go", nil
+	case formatGo:
+		return true, nil
+	case formatNone:
+		return false, nil
+	}
+	return false, errors.Errorf("unknown format %q (use %s, %s or %s)", mode, formatAuto, formatGo, formatNone)
+}
+
+// formatGoSource formats src with go/format. If the source can't be
+// parsed, the error includes the offending line.
+func formatGoSource(src string) (string, error) {
+	b, err := format.Source([]byte(src))
+	if err != nil {
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			return "", err
+		}
+		pos := list[0].Pos
+		lines := strings.Split(src, "\n")
+		if pos.Line < 1 || pos.Line > len(lines) {
+			return "", err
+		}
+		return "", errors.Errorf("%d:%d: %s\n\t%s", pos.Line, pos.Column, list[0].Msg, strings.TrimSpace(lines[pos.Line-1]))
+	}
+	return string(b), nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestShouldFormat(t *testing.T) {
+	is := is.New(t)
+	for _, tc := range []struct {
+		mode   string
+		target target
+		want   bool
+	}{
+		{"", target{template: "server.go.plush", out: "oto.gen.go"}, true},
+		{"auto", target{template: "client.js.plush", out: "oto.gen.js"}, false},
+		{"auto", target{template: "server.go.plush"}, true},
+		{"auto", target{template: "server.plush", out: "server.txt"}, false},
+		{"go", target{template: "server.plush", out: "server.txt"}, true},
+		{"none", target{template: "server.go.plush", out: "oto.gen.go"}, false},
+	} {
+		got, err := shouldFormat(tc.mode, tc.target)
+		is.NoErr(err)
+		is.Equal(got, tc.want) // shouldFormat(tc.mode, tc.target)
+	}
+	_, err := shouldFormat("gofmt", target{})
+	is.True(err != nil)
+}
+
+func TestFormatGoSource(t *testing.T) {
+	is := is.New(t)
+	out, err := formatGoSource("package main\nfunc main() {\n\t\tprintln( \"hi\" )\n}\n")
+	is.NoErr(err)
+	is.Equal(out, "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+
+	_, err = formatGoSource("package main\n\nfunc main() {\n\tprintln(\"hi\"\n}\n")
+	is.True(err != nil)
+	is.True(strings.HasPrefix(err.Error(), "4:14: "))
+	is.True(strings.HasSuffix(err.Error(), "\n\tprintln(\"hi\"")) // offending line
+}
+
+func TestRunFormat(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.go.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`package <%= def.PackageName %>
+<%= for (service) in def.Services { %>
+type <%= service.Name %> interface {
+		Methods() int
+}
+<% } %>`), 0666)
+	is.NoErr(err)
+	outPath := filepath.Join(dir, "out.go")
+	var buf bytes.Buffer
+	err = run(&buf, []string{
+		"oto",
+		"-template", templatePath,
+		"-out", outPath,
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "type GreeterService interface {\n\tMethods() int\n}\n"))
+
+	err = run(&buf, []string{
+		"oto",
+		"-format", "none",
+		"-template", templatePath,
+		"-out", outPath,
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err = ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "\t\tMethods() int\n")) // not formatted
+
+	err = ioutil.WriteFile(templatePath, []byte(`package <%= def.PackageName %>
+<%= for (service) in def.Services { %>
+type <%= service.Name %> interface {
+	Methods( int
+}
+<% } %>`), 0666)
+	is.NoErr(err)
+	err = run(&buf, []string{
+		"oto",
+		"-template", templatePath,
+		"-out", outPath,
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "format "+templatePath))
+	is.True(strings.Contains(err.Error(), "\tMethods( int")) // offending line
+}
@@ -6,10 +6,12 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
+	"github.com/sbward/oto/builtin"
 	"github.com/sbward/oto/parser"
 	"github.com/sbward/oto/render"
 )
@@ -34,67 +36,187 @@ flags:`)
 		flags.PrintDefaults()
 	}
 	var (
-		template   = flags.String("template", "", "plush template to render")
-		outfile    = flags.String("out", "", "output file (default: stdout)")
-		pkg        = flags.String("pkg", "", "explicit package name (default: inferred)")
-		v          = flags.Bool("v", false, "verbose output")
-		paramsStr  = flags.String("params", "", "list of parameters in the format: \"key:value,key:value\"")
-		ignoreList = flags.String("ignore", "", "comma separated list of interfaces to ignore")
-		matchList  = flags.String("match", "", "comma separated list of interfaces to match")
+		templates    stringsFlag
+		outfiles     stringsFlag
+		templatesDir = flags.String("templates", "", "directory of templates (.plush, .tmpl or .gotmpl) to render into -outdir")
+		outDir       = flags.String("outdir", "", "output directory for -templates and -eject (default: current directory)")
+		listBuiltins = flags.Bool("list-templates", false, "list the builtin templates")
+		ejectList    = flags.String("eject", "", "comma separated list of builtin templates to copy into -outdir for customization")
+		pkg          = flags.String("pkg", "", "explicit package name (default: inferred)")
+		v            = flags.Bool("v", false, "verbose output")
+		paramsStr    = flags.String("params", "", "list of string parameters in the format: \"key:value,key:value\"")
+		paramsFile   = flags.String("params-file", "", "JSON or YAML file of parameters")
+		ignoreList   = flags.String("ignore", "", "comma separated list of interfaces to ignore")
+		matchList    = flags.String("match", "", "comma separated list of interfaces to match")
+		configPath   = flags.String("config", "", "config file (default: "+strings.Join(configFilenames, ", ")+" when no templates are given)")
+		watchMode    = flags.Bool("watch", false, "regenerate outputs when the definition or templates change")
+		checkMode    = flags.Bool("check", false, "check the outputs are up to date, printing a diff if not (nothing is written)")
+		definition   = flags.String("definition", "", "JSON definition file (from -dump json) to use instead of parsing Go packages")
+		dumpFormat   = flags.String("dump", "", "write the parsed definition to stdout as json or yaml, instead of rendering templates")
+		engineName   = flags.String("engine", "", "template engine: plush or text (default: text for .tmpl and .gotmpl templates, otherwise plush)")
+		formatMode   = flags.String("format", "", "format outputs as Go source: auto (.go outputs), go (every output) or none (default: auto)")
+		strict       = flags.Bool("strict", false, "make missing params an error, instead of rendering nothing")
 	)
+	flags.Var(&templates, "template", "plush template to render, or builtin:name for a builtin template (may be repeated)")
+	flags.Var(&outfiles, "out", "output file (default: stdout), one for each -template, or a pattern like out/{{service.Name}}.go for a file per service or object")
+	var paramFlags stringsFlag
+	flags.Var(&paramFlags, "param", "parameter in the format key=value, where JSON values are decoded (may be repeated)")
+	var includeFlags, libraryFlags stringsFlag
+	flags.Var(&includeFlags, "include", "directory to search for partials, after the template's own directory (may be repeated)")
+	flags.Var(&libraryFlags, "library", "partial of shared macros to load before every template (may be repeated)")
+	var typeFlags stringsFlag
+	flags.Var(&typeFlags, "type", "type mapping for type_for in the format lang:GoType=Type, like kotlin:time.Time=Instant (may be repeated)")
+	acronymsList := flags.String("acronyms", "", "comma separated list of acronyms to add (like OAuth,SKU), or remove with a - prefix (like -CAT)")
 	if err := flags.Parse(args[1:]); err != nil {
 		return err
 	}
-	if *template == "" {
-		flags.PrintDefaults()
-		return errors.New("missing template")
+	if *listBuiltins {
+		return listTemplates(stdout)
+	}
+	if *ejectList != "" {
+		return eject(stdout, strings.Split(*ejectList, ","), *outDir)
+	}
+	hasTemplateFlags := len(templates) > 0 || *templatesDir != ""
+	if *configPath == "" && !hasTemplateFlags && *dumpFormat == "" {
+		*configPath = findConfig()
+	}
+	var cfg config
+	if *configPath != "" {
+		var err error
+		cfg, err = loadConfig(*configPath)
+		if err != nil {
+			return errors.Wrap(err, "config")
+		}
 	}
-	params, err := parseParams(*paramsStr)
+	// flags override the config
+	targets := cfg.targets()
+	if *dumpFormat == "" && (len(targets) == 0 || hasTemplateFlags) {
+		var err error
+		targets, err = parseTargets(templates, outfiles, *templatesDir, *outDir)
+		if err != nil {
+			flags.PrintDefaults()
+			return err
+		}
+	}
+	flagParams, err := parseParams(*paramsStr)
 	if err != nil {
 		flags.PrintDefaults()
 		return errors.Wrap(err, "params")
 	}
-	p := parser.New(flags.Args()...)
+	// later sources override earlier ones
+	params := make(map[string]interface{})
+	for k, v := range cfg.Params {
+		params[k] = v
+	}
+	if *paramsFile != "" {
+		fileParams, err := loadParamsFile(*paramsFile)
+		if err != nil {
+			return errors.Wrap(err, "params-file")
+		}
+		for k, v := range fileParams {
+			params[k] = v
+		}
+	}
+	for k, v := range flagParams {
+		params[k] = v
+	}
+	for _, paramFlag := range paramFlags {
+		k, v, err := parseParam(paramFlag)
+		if err != nil {
+			return errors.Wrap(err, "param")
+		}
+		params[k] = v
+	}
+	if _, err := interpolate(params); err != nil {
+		return errors.Wrap(err, "params")
+	}
+	for _, t := range targets {
+		if _, err := interpolate(t.params); err != nil {
+			return errors.Wrapf(err, "%s: params", t.template)
+		}
+	}
+	g := generator{
+		patterns:   flags.Args(),
+		ignore:     cfg.Ignore,
+		match:      cfg.Match,
+		pkg:        cfg.Pkg,
+		definition: cfg.Definition,
+		params:     params,
+		engine:     cfg.Engine,
+		include:    cfg.Include,
+		libraries:  cfg.Libraries,
+		format:     cfg.Format,
+		strict:     cfg.Strict || *strict,
+		verbose:    *v,
+	}
+	if *definition != "" {
+		g.definition = *definition
+	}
+	if *engineName != "" {
+		g.engine = *engineName
+	}
+	if len(includeFlags) > 0 {
+		g.include = includeFlags
+	}
+	if len(libraryFlags) > 0 {
+		g.libraries = libraryFlags
+	}
+	acronymChanges := cfg.Acronyms
+	if *acronymsList != "" {
+		acronymChanges = append(acronymChanges, strings.Split(*acronymsList, ",")...)
+	}
+	if len(acronymChanges) > 0 {
+		g.acronyms = parseAcronyms(acronymChanges)
+	}
+	typeMaps, err := parseTypes(typeFlags)
+	if err != nil {
+		return errors.Wrap(err, "type")
+	}
+	g.typeMaps = mergeTypeMaps(cfg.Types, typeMaps)
+	if g.engine != "" {
+		if _, err := render.NewEngine(g.engine, render.Options{}); err != nil {
+			return err
+		}
+	}
+	if *formatMode != "" {
+		g.format = *formatMode
+	}
+	if _, err := shouldFormat(g.format, target{}); err != nil {
+		return err
+	}
+	if len(g.patterns) == 0 {
+		g.patterns = cfg.Packages
+	}
 	ignoreItems := strings.Split(*ignoreList, ",")
 	if ignoreItems[0] != "" {
-		p.ExcludeInterfaces = ignoreItems
+		g.ignore = ignoreItems
 	}
 	matchItems := strings.Split(*matchList, ",")
 	if matchItems[0] != "" {
-		p.IncludeInterfaces = matchItems
-	}
-	p.Verbose = *v
-	if p.Verbose {
-		fmt.Println("oto - github.com/sbward/oto", Version)
-	}
-	def, err := p.Parse()
-	if err != nil {
-		return errors.Wrap(err, "parse")
+		g.match = matchItems
 	}
 	if *pkg != "" {
-		def.PackageName = *pkg
-	}
-	b, err := ioutil.ReadFile(*template)
-	if err != nil {
-		return errors.Wrap(err, "readfile")
+		g.pkg = *pkg
 	}
-	out, err := render.Render(string(b), def, params)
-	if err != nil {
-		return errors.Wrap(err, "render")
+	if g.verbose {
+		fmt.Println("oto - github.com/sbward/oto", Version)
 	}
-	var w io.Writer = stdout
-	if *outfile != "" {
-		f, err := os.Create(*outfile)
-		if err != nil {
-			return errors.Wrap(err, "create outfile")
+	if *watchMode {
+		for _, t := range targets {
+			if t.out == "" {
+				return errors.Errorf("%s: -watch needs an output file for every template", t.template)
+			}
 		}
-		defer f.Close()
-		w = f
+		return watch(stdout, g, targets, cfg, watchInterval, nil)
 	}
-	if _, err := io.WriteString(w, out); err != nil {
-		return errors.Wrap(err, "write outfile")
+	def, _, err := g.parse()
+	if err != nil {
+		return errors.Wrap(err, "parse")
 	}
-	if p.Verbose {
+	if *dumpFormat != "" {
+		return dump(stdout, def, *dumpFormat)
+	}
+	if g.verbose {
 		var methodsCount int
 		for i := range def.Services {
 			methodsCount += len(def.Services[i].Methods)
@@ -103,11 +225,272 @@ flags:`)
 		fmt.Printf("\tTotal services: %d", len(def.Services))
 		fmt.Printf("\tTotal Methods: %d", methodsCount)
 		fmt.Printf("\tTotal Objects: %d\n", len(def.Objects))
-		fmt.Printf("\tOutput size: %s\n", humanize.Bytes(uint64(len(out))))
+	}
+	if *checkMode {
+		return check(stdout, g, targets, def)
+	}
+	var failures []string
+	claimed := g.outPaths(targets, def)
+	for _, t := range targets {
+		outputs, err := g.renderTarget(stdout, t, def, claimed)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		if g.verbose {
+			fmt.Printf("\t%s: %s\n", t, humanize.Bytes(outputsSize(outputs)))
+		}
+	}
+	if len(targets) == 1 && len(failures) == 1 {
+		return errors.New(failures[0])
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("%d of %d targets failed:\n%s", len(failures), len(targets), strings.Join(failures, "\n"))
+	}
+	if err := cfg.runPost(stdout, os.Stderr); err != nil {
+		return err
 	}
 	return nil
 }
 
+// generator parses Oto definitions and renders targets.
+type generator struct {
+	// patterns are the definition package patterns.
+	patterns []string
+	// ignore and match are lists of interfaces to exclude
+	// or include.
+	ignore []string
+	match  []string
+	// pkg is the explicit package name.
+	pkg string
+	// definition is a JSON definition file to load instead of
+	// parsing the patterns.
+	definition string
+	// params are passed to every template.
+	params map[string]interface{}
+	// engine is the name of the render.Engine to use, or empty to
+	// choose by the template's extension.
+	engine string
+	// include are directories searched for partials, and
+	// libraries are partials loaded before every template.
+	include   []string
+	libraries []string
+	// acronyms are the words kept together in names, or nil for
+	// parser.DefaultAcronyms.
+	acronyms []string
+	// typeMaps are the type mappings for type_for.
+	typeMaps map[string]render.TypeMap
+	// format is how outputs are formatted, see shouldFormat.
+	format string
+	// strict makes missing params an error.
+	strict  bool
+	verbose bool
+}
+
+// parse parses the definition, also returning the files it was
+// parsed from.
+func (g generator) parse() (parser.Definition, []string, error) {
+	if g.definition != "" {
+		def, err := parser.LoadDefinitionFile(g.definition)
+		if err != nil {
+			return def, []string{g.definition}, err
+		}
+		if g.pkg != "" {
+			def.PackageName = g.pkg
+		}
+		return def, []string{g.definition}, nil
+	}
+	p := parser.New(g.patterns...)
+	p.ExcludeInterfaces = g.ignore
+	p.IncludeInterfaces = g.match
+	p.Verbose = g.verbose
+	p.Acronyms = g.acronyms
+	def, err := p.Parse()
+	if err != nil {
+		return def, p.Files(), err
+	}
+	if g.pkg != "" {
+		def.PackageName = g.pkg
+	}
+	return def, p.Files(), nil
+}
+
+// target is a template to render, and the file to write the
+// output to.
+type target struct {
+	template string
+	// out is the output file, or empty for stdout.
+	out string
+	// params are passed to the template in addition to the
+	// params for every target.
+	params map[string]interface{}
+	// typeMaps are merged over the type maps for every target.
+	typeMaps map[string]render.TypeMap
+}
+
+func (t target) String() string {
+	if t.out == "" {
+		return t.template
+	}
+	return t.template + " -> " + t.out
+}
+
+// isTemplateExt gets whether ext is the extension of a template
+// file, which is removed to get the output filename.
+func isTemplateExt(ext string) bool {
+	switch ext {
+	case ".plush", ".tmpl", ".gotmpl":
+		return true
+	}
+	return false
+}
+
+// parseTargets pairs each template with its output file, and adds a
+// target for every template in templatesDir, except partials.
+func parseTargets(templates, outfiles []string, templatesDir, outDir string) ([]target, error) {
+	var targets []target
+	switch {
+	case len(templates) == 1 && len(outfiles) == 0:
+		targets = append(targets, target{template: templates[0]})
+	case len(templates) != len(outfiles):
+		return nil, errors.Errorf("%d -template flags but %d -out flags (need one -out for each -template)", len(templates), len(outfiles))
+	default:
+		for i := range templates {
+			targets = append(targets, target{template: templates[i], out: outfiles[i]})
+		}
+	}
+	if templatesDir != "" {
+		entries, err := ioutil.ReadDir(templatesDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "templates")
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			// partials start with an underscore
+			if entry.IsDir() || !isTemplateExt(ext) || strings.HasPrefix(entry.Name(), "_") {
+				continue
+			}
+			targets = append(targets, target{
+				template: filepath.Join(templatesDir, entry.Name()),
+				out:      filepath.Join(outDir, strings.TrimSuffix(entry.Name(), ext)),
+			})
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("missing template")
+	}
+	return targets, nil
+}
+
+// renderTarget renders the target and writes its outputs, returning
+// them, and removes its stale outputs, except those claimed by other
+// targets. See writeFile and outPaths.
+func (g generator) renderTarget(stdout io.Writer, t target, def parser.Definition, claimed map[string]bool) ([]output, error) {
+	outputs, stale, err := g.render(t, def, claimed)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range outputs {
+		if o.path == "" {
+			if _, err := io.WriteString(stdout, o.content); err != nil {
+				return nil, errors.Wrap(err, "write")
+			}
+			continue
+		}
+		written, err := writeFile(o.path, []byte(o.content))
+		if err != nil {
+			return nil, errors.Wrap(err, "write outfile")
+		}
+		if g.verbose && !written {
+			fmt.Printf("\t%s: unchanged\n", o.path)
+		}
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrap(err, "remove stale outfile")
+		}
+		if g.verbose {
+			fmt.Printf("\t%s: removed\n", path)
+		}
+	}
+	return outputs, nil
+}
+
+// render renders the target's outputs, along with the stale outputs
+// to remove. See renderEach.
+func (g generator) render(t target, def parser.Definition, claimed map[string]bool) ([]output, []string, error) {
+	src, err := readTemplate(t.template)
+	if err != nil {
+		return nil, nil, err
+	}
+	options := render.Options{
+		Includes: render.Includes{
+			Paths:     g.include,
+			Libraries: g.libraries,
+		},
+		Acronyms: g.acronyms,
+		TypeMaps: mergeTypeMaps(g.typeMaps, t.typeMaps),
+		Name:     t.template,
+		Strict:   g.strict,
+	}
+	if !builtin.IsBuiltin(t.template) {
+		// partials next to the template come first
+		options.Includes.Paths = append([]string{filepath.Dir(t.template)}, g.include...)
+	}
+	each, err := render.Each(t.out)
+	if err != nil {
+		return nil, nil, err
+	}
+	if each != "" {
+		return g.renderEach(t, each, src, def, options, claimed)
+	}
+	out, err := g.renderOutput(t, src, def, options)
+	if err != nil {
+		// the error says which template
+		return nil, nil, errors.Wrap(err, "render")
+	}
+	return []output{{path: t.out, content: out}}, nil, nil
+}
+
+// renderOutput renders the template source, formatting the output if
+// it is Go source.
+func (g generator) renderOutput(t target, src string, def parser.Definition, options render.Options) (string, error) {
+	params := g.params
+	if len(t.params) > 0 {
+		targetParams := make(map[string]interface{})
+		for k, v := range params {
+			targetParams[k] = v
+		}
+		for k, v := range t.params {
+			targetParams[k] = v
+		}
+		params = targetParams
+	}
+	engine := render.EngineForFile(templateFilename(t.template), options)
+	if g.engine != "" {
+		var err error
+		engine, err = render.NewEngine(g.engine, options)
+		if err != nil {
+			return "", err
+		}
+	}
+	out, err := engine.Render(src, def, params)
+	if err != nil {
+		return "", err
+	}
+	format, err := shouldFormat(g.format, t)
+	if err != nil {
+		return "", err
+	}
+	if format {
+		out, err = formatGoSource(out)
+		if err != nil {
+			return "", errors.Wrapf(err, "format %s", t)
+		}
+	}
+	return out, nil
+}
+
 // parseParams returns a map of data parsed from the params string.
 func parseParams(s string) (map[string]interface{}, error) {
 	params := make(map[string]interface{})
@@ -118,7 +501,7 @@ func parseParams(s string) (map[string]interface{}, error) {
 	pairs := strings.Split(s, ",")
 	for i := range pairs {
 		pair := strings.TrimSpace(pairs[i])
-		segs := strings.Split(pair, ":")
+		segs := strings.SplitN(pair, ":", 2)
 		if len(segs) != 2 {
 			return nil, errors.New("malformed params")
 		}
@@ -126,3 +509,67 @@ func parseParams(s string) (map[string]interface{}, error) {
 	}
 	return params, nil
 }
+
+// parseAcronyms gets parser.DefaultAcronyms with the changes, which
+// are acronyms to add, or to remove when prefixed with -.
+func parseAcronyms(changes []string) []string {
+	var add, remove []string
+	for _, change := range changes {
+		change = strings.TrimSpace(change)
+		if strings.HasPrefix(change, "-") {
+			remove = append(remove, strings.TrimPrefix(change, "-"))
+			continue
+		}
+		add = append(add, change)
+	}
+	return parser.Acronyms(add, remove)
+}
+
+// parseTypes parses -type flags, in the format lang:GoType=Type.
+func parseTypes(flags []string) (map[string]render.TypeMap, error) {
+	typeMaps := make(map[string]render.TypeMap)
+	for _, flag := range flags {
+		segs := strings.SplitN(flag, ":", 2)
+		if len(segs) != 2 {
+			return nil, errors.Errorf("malformed type %q (expected lang:GoType=Type)", flag)
+		}
+		lang := segs[0]
+		segs = strings.SplitN(segs[1], "=", 2)
+		if len(segs) != 2 || lang == "" || segs[0] == "" {
+			return nil, errors.Errorf("malformed type %q (expected lang:GoType=Type)", flag)
+		}
+		typeMap := typeMaps[lang]
+		if typeMap.Types == nil {
+			typeMap.Types = make(map[string]string)
+		}
+		typeMap.Types[segs[0]] = segs[1]
+		typeMaps[lang] = typeMap
+	}
+	return typeMaps, nil
+}
+
+// mergeTypeMaps gets the type maps in a, with those in b merged
+// over them.
+func mergeTypeMaps(a, b map[string]render.TypeMap) map[string]render.TypeMap {
+	merged := make(map[string]render.TypeMap, len(a)+len(b))
+	for lang, typeMap := range a {
+		merged[lang] = typeMap
+	}
+	for lang, typeMap := range b {
+		merged[lang] = merged[lang].Merge(typeMap)
+	}
+	return merged
+}
+
+// stringsFlag is a flag.Value that may be repeated, collecting
+// each value.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
 )
 
 func Test(t *testing.T) {
@@ -41,4 +45,247 @@ func TestParseParams(t *testing.T) {
 	is.Equal(params["key2"], "value2")
 	is.Equal(params["key3"], "value3")
 
+	params, err = parseParams("endpoint:https://example.com:8080/oto/")
+	is.NoErr(err)
+	is.Equal(params["endpoint"], "https://example.com:8080/oto/")
+
+	_, err = parseParams("key1")
+	is.True(err != nil)
+}
+
+func TestMultipleTargets(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "objects.plush"), []byte(`<%= for (object) in def.Objects { %><%= object.Name %>
+<% } %>`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	args := []string{
+		"oto",
+		"-template=./testdata/template.plush",
+		"-out=" + filepath.Join(dir, "methods.txt"),
+		"-template=" + filepath.Join(dir, "objects.plush"),
+		"-out=" + filepath.Join(dir, "objects.txt"),
+		"./testdata/services/pleasantries",
+	}
+	err = run(&buf, args)
+	is.NoErr(err)
+	is.Equal(buf.String(), "") // nothing written to stdout
+	b, err := ioutil.ReadFile(filepath.Join(dir, "methods.txt"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "GreeterService.Greet"))
+	b, err = ioutil.ReadFile(filepath.Join(dir, "objects.txt"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "GreetRequest"))
+}
+
+func TestTemplatesDir(t *testing.T) {
+	is := is.New(t)
+	templatesDir := t.TempDir()
+	outDir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(templatesDir, "methods.txt.plush"), []byte(`<%= for (service) in def.Services { %><%= service.Name %>
+<% } %>`), 0666)
+	is.NoErr(err)
+	err = ioutil.WriteFile(filepath.Join(templatesDir, "broken.txt.plush"), []byte(`<%= nope( %>`), 0666)
+	is.NoErr(err)
+	err = ioutil.WriteFile(filepath.Join(templatesDir, "README.md"), []byte(`not a template`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	args := []string{
+		"oto",
+		"-templates=" + templatesDir,
+		"-outdir=" + outDir,
+		"./testdata/services/pleasantries",
+	}
+	err = run(&buf, args)
+	is.True(err != nil) // broken template should fail
+	is.True(strings.Contains(err.Error(), "1 of 2 targets failed"))
+	is.True(strings.Contains(err.Error(), "broken.txt.plush"))
+	// other templates are still rendered
+	b, err := ioutil.ReadFile(filepath.Join(outDir, "methods.txt"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "GreeterService"))
+	_, err = os.Stat(filepath.Join(outDir, "README"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestParseTargets(t *testing.T) {
+	is := is.New(t)
+
+	targets, err := parseTargets([]string{"a.plush"}, nil, "", "")
+	is.NoErr(err)
+	is.Equal(targets, []target{{template: "a.plush"}})
+
+	targets, err = parseTargets([]string{"a.plush", "b.plush"}, []string{"a.go", "b.js"}, "", "")
+	is.NoErr(err)
+	is.Equal(targets, []target{{template: "a.plush", out: "a.go"}, {template: "b.plush", out: "b.js"}})
+
+	_, err = parseTargets([]string{"a.plush", "b.plush"}, []string{"a.go"}, "", "")
+	is.True(err != nil) // mismatched -template and -out
+
+	_, err = parseTargets(nil, nil, "", "")
+	is.Equal(err.Error(), "missing template")
+}
+
+func TestRunTextTemplate(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "services.txt.tmpl")
+	err := ioutil.WriteFile(templatePath, []byte(`{{ range .def.Services }}{{ .Name }} {{ camelize_down .Name }} {{ $.params.Suffix }}
+{{ end }}`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{
+		"oto",
+		"-templates", dir,
+		"-outdir", dir,
+		"-ignore", "Ignorer",
+		"-params", "Suffix:<ok>",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "services.txt"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "GreeterService greeterService <ok>\n"))
+
+	// -engine overrides the extension
+	templatePath = filepath.Join(dir, "services.plush")
+	err = ioutil.WriteFile(templatePath, []byte(`{{ .def.PackageName }}`), 0666)
+	is.NoErr(err)
+	buf.Reset()
+	err = run(&buf, []string{"oto", "-engine", "text", "-template", templatePath, "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	is.Equal(buf.String(), "pleasantries")
+
+	err = run(&buf, []string{"oto", "-engine", "mustache", "-template", templatePath, "./testdata/services/pleasantries"})
+	is.True(err != nil)
+}
+
+func TestRunPartials(t *testing.T) {
+	is := is.New(t)
+	dir, shared := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "services.txt.plush"):   "<%= header() %>\n" + `<%= partial("_services.plush", {"services": def.Services}) %>`,
+		filepath.Join(dir, "_services.plush"):      `<%= for (service) in services { %><%= partial("service.plush", {"service": service}) %><% } %>`,
+		filepath.Join(shared, "service.plush"):     "<%= service.Name %>\n",
+		filepath.Join(shared, "macros.plush"):      `<% let header = fn() { return "# " + def.PackageName } %>`,
+		filepath.Join(shared, "unused.txt.plush"):  "unused",
+		filepath.Join(dir, "_ignored.txt.plush"):   "partials are not targets",
+		filepath.Join(dir, "other.txt.plush"):      "other",
+		filepath.Join(shared, "not-a-partial.txt"): "",
+	}
+	for path, src := range files {
+		err := ioutil.WriteFile(path, []byte(src), 0666)
+		is.NoErr(err)
+	}
+	var buf bytes.Buffer
+	err := run(&buf, []string{
+		"oto",
+		"-templates", dir,
+		"-outdir", dir,
+		"-include", shared,
+		"-library", "macros.plush",
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "services.txt"))
+	is.NoErr(err)
+	is.Equal(string(b), "# pleasantries\nGreeterService\nWelcomer\n")
+	_, err = os.Stat(filepath.Join(dir, "_ignored.txt"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestParseAcronyms(t *testing.T) {
+	is := is.New(t)
+	acronyms := parseAcronyms([]string{"OAuth", " SKU", "-CAT"})
+	is.Equal(acronyms, parser.Acronyms([]string{"OAuth", "SKU"}, []string{"CAT"}))
+}
+
+func TestRunAcronyms(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= for (service) in def.Services { %><%= for (method) in service.Methods { %><%= method.NameLowerCamel %> <%= camelize_down(method.Name + "SKU") %>
+<% } %><% } %>`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{
+		"oto",
+		"-template", templatePath,
+		"-ignore", "Ignorer,Welcomer",
+		"-acronyms", "GetGreetings,SKU",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	is.True(strings.Contains(buf.String(), "getgreetings getgreetingsSKU\n"))
+	is.True(strings.Contains(buf.String(), "greet greetSKU\n"))
+}
+
+func TestParseTypes(t *testing.T) {
+	is := is.New(t)
+	typeMaps, err := parseTypes([]string{"kotlin:time.Time=Instant", "kotlin:github.com/google/uuid.UUID=UUID", "dart:int64=BigInt"})
+	is.NoErr(err)
+	is.Equal(typeMaps["kotlin"].Types, map[string]string{"time.Time": "Instant", "github.com/google/uuid.UUID": "UUID"})
+	is.Equal(typeMaps["dart"].Types, map[string]string{"int64": "BigInt"})
+	_, err = parseTypes([]string{"time.Time=Instant"})
+	is.True(err != nil)
+	_, err = parseTypes([]string{"kotlin:time.Time"})
+	is.True(err != nil)
+}
+
+func TestRunTypes(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= for (object) in def.Objects { %><%= if (object.Name == "WelcomeRequest") { %><%= for (field) in object.Fields { %><%= field.Name %>: <%= type_for("kotlin", field.Type) %>
+<% } %><% } %><% } %>`), 0666)
+	is.NoErr(err)
+	packagePath, err := filepath.Abs("./testdata/services/pleasantries")
+	is.NoErr(err)
+	configPath := filepath.Join(dir, "oto.yaml")
+	err = ioutil.WriteFile(configPath, []byte(`
+packages: [`+packagePath+`]
+types:
+  kotlin:
+    types:
+      string: KString
+targets:
+  - template: `+templatePath+`
+    out: `+filepath.Join(dir, "out.txt")+`
+    types:
+      kotlin:
+        types:
+          bool: KBoolean
+`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{"oto", "-config", configPath, "-type", "kotlin:int=KInt"})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "out.txt"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "To: KString\n"))
+	is.True(strings.Contains(string(b), "Times: KInt\n"))
+	is.True(strings.Contains(string(b), "NewCustomer: KBoolean\n"))
+}
+
+func TestRunErrors(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= for (service) in def.Services { %>
+<%= service.Name %> <%= params["Suffix"] %>
+<%= service.Nope %>
+<% } %>`), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{"oto", "-template", templatePath, "-ignore", "Ignorer", "./testdata/services/pleasantries"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), templatePath+":3: 'service' does not have a field or method named 'Nope'"))
+	is.True(strings.Contains(err.Error(), "inside for (service) in def.Services\n"))
+	is.True(strings.Contains(err.Error(), "> 3 | <%= service.Nope %>"))
+
+	err = run(&buf, []string{"oto", "-template", templatePath, "-strict", "./testdata/services/pleasantries"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), templatePath+`:2: params["Suffix"] is not set`))
 }
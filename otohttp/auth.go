@@ -0,0 +1,83 @@
+package otohttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// ErrUnauthorized may be returned by an Authorizer to reject a request
+// that is not authenticated. The caller gets a 401 response.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden may be returned by an Authorizer to reject a request
+// that is not allowed. The caller gets a 403 response.
+var ErrForbidden = errors.New("forbidden")
+
+// Authorizer decides whether requests may call service methods.
+type Authorizer interface {
+	// Authorize is called before the request is decoded, with the
+	// comment metadata of the method being called.
+	// Returning ErrUnauthorized rejects the request with a 401
+	// response, any other error rejects it with a 403 response.
+	Authorize(r *http.Request, service, method string, metadata map[string]interface{}) error
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions
+// as an Authorizer.
+type AuthorizerFunc func(r *http.Request, service, method string, metadata map[string]interface{}) error
+
+// Authorize calls fn(r, service, method, metadata).
+func (fn AuthorizerFunc) Authorize(r *http.Request, service, method string, metadata map[string]interface{}) error {
+	return fn(r, service, method, metadata)
+}
+
+// RegisterMetadata sets the comment metadata for the specified
+// service method. The metadata is a JSON object, and is passed to the
+// Authorizer.
+// Generated code calls RegisterMetadata when registering services.
+// It panics if metadataJSON is not a valid JSON object.
+func (s *Server) RegisterMetadata(service, method, metadataJSON string) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+		panic(fmt.Sprintf("otohttp: RegisterMetadata %s.%s: %s", service, method, err))
+	}
+	if s.metadata == nil {
+		s.metadata = make(map[string]map[string]interface{})
+	}
+	s.metadata[service+"."+method] = metadata
+}
+
+// Metadata gets the comment metadata registered for the specified
+// service method, or nil if there is none.
+func (s *Server) Metadata(service, method string) map[string]interface{} {
+	return s.metadata[service+"."+method]
+}
+
+// authorize wraps next with a check of the Authorizer, if there is one.
+func (s *Server) authorize(service, method string, next http.Handler) http.Handler {
+	if s.Authorizer == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := s.Authorizer.Authorize(r, service, method, s.Metadata(service, method))
+		if err == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		status := http.StatusForbidden
+		if errors.Is(err, ErrUnauthorized) {
+			status = http.StatusUnauthorized
+		}
+		errObj := struct {
+			Error string `json:"error"`
+		}{
+			Error: err.Error(),
+		}
+		if err := Encode(w, r, status, errObj); err != nil {
+			log.Printf("failed to encode error: %s\n", err)
+		}
+	})
+}
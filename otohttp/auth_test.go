@@ -0,0 +1,78 @@
+package otohttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestServerAuthorizer(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	var decoded bool
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decoded = true
+		w.Write([]byte(`{"greeting":"Hi Mat"}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.RegisterMetadata("Service", "Method", `{"auth":"admin"}`)
+	srv.Register("Service", "Public", h)
+	srv.Authorizer = AuthorizerFunc(func(r *http.Request, service, method string, metadata map[string]interface{}) error {
+		if metadata["auth"] == nil {
+			return nil
+		}
+		switch r.Header.Get("Authorization") {
+		case "":
+			return ErrUnauthorized
+		case "admin":
+			return nil
+		}
+		return fmt.Errorf("%s.%s: %w", service, method, ErrForbidden)
+	})
+	call := func(method, auth string) *httptest.ResponseRecorder {
+		decoded = false
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service."+method, strings.NewReader(`{"name":"Mat"}`))
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call("Method", "")
+	is.Equal(w.Code, http.StatusUnauthorized)
+	is.Equal(w.Body.String(), `{"error":"unauthorized"}`)
+	is.True(!decoded) // handler should not be called
+
+	w = call("Method", "someone")
+	is.Equal(w.Code, http.StatusForbidden)
+	is.Equal(w.Body.String(), `{"error":"Service.Method: forbidden"}`)
+	is.True(!decoded) // handler should not be called
+
+	w = call("Method", "admin")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(decoded)
+
+	w = call("Public", "")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(decoded)
+}
+
+func TestServerRegisterMetadata(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.RegisterMetadata("Service", "Method", `{"auth":"admin","featured":true}`)
+	is.Equal(srv.Metadata("Service", "Method")["auth"], "admin")
+	is.Equal(srv.Metadata("Service", "Method")["featured"], true)
+	is.Equal(srv.Metadata("Service", "Other"), nil)
+
+	defer func() {
+		is.True(recover() != nil) // invalid JSON should panic
+	}()
+	srv.RegisterMetadata("Service", "Method", `not json`)
+}
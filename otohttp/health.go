@@ -0,0 +1,100 @@
+package otohttp
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Check checks the health or readiness of some dependency of
+// the server, returning an error if it is not ok.
+type Check func(ctx context.Context) error
+
+// namedCheck is a Check with a name.
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// AddHealthCheck adds a Check that is run by the Healthz handler.
+func (s *Server) AddHealthCheck(name string, check Check) {
+	s.healthChecks = append(s.healthChecks, namedCheck{name: name, check: check})
+}
+
+// AddReadyCheck adds a Check that is run by the Readyz handler.
+func (s *Server) AddReadyCheck(name string, check Check) {
+	s.readyChecks = append(s.readyChecks, namedCheck{name: name, check: check})
+}
+
+// Healthz gets an http.Handler that runs the health checks, suitable
+// for a liveness probe.
+// It responds with http.StatusOK if every check passes, or
+// http.StatusServiceUnavailable if any fail.
+func (s *Server) Healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveChecks(w, r, s.healthChecks)
+	})
+}
+
+// Readyz gets an http.Handler that runs the ready checks, suitable
+// for a readiness probe.
+// It responds with http.StatusOK if every check passes, or
+// http.StatusServiceUnavailable if any fail.
+func (s *Server) Readyz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveChecks(w, r, s.readyChecks)
+	})
+}
+
+func serveChecks(w http.ResponseWriter, r *http.Request, checks []namedCheck) {
+	response := struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}{
+		Status: "ok",
+		Checks: make(map[string]string),
+	}
+	status := http.StatusOK
+	for _, c := range checks {
+		if err := c.check(r.Context()); err != nil {
+			response.Checks[c.name] = err.Error()
+			response.Status = "failed"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		response.Checks[c.name] = "ok"
+	}
+	if err := Encode(w, r, status, response); err != nil {
+		log.Printf("failed to encode checks: %s\n", err)
+	}
+}
+
+// Routes gets the sorted list of registered routes, in the
+// form "Service.Method".
+func (s *Server) Routes() []string {
+	routes := make([]string, 0, len(s.routes))
+	for path := range s.routes {
+		routes = append(routes, strings.TrimPrefix(path, s.Basepath))
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// RoutesHandler gets an http.Handler that lists the registered
+// routes.
+func (s *Server) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := struct {
+			Basepath string   `json:"basepath"`
+			Routes   []string `json:"routes"`
+		}{
+			Basepath: s.Basepath,
+			Routes:   s.Routes(),
+		}
+		if err := Encode(w, r, http.StatusOK, response); err != nil {
+			log.Printf("failed to encode routes: %s\n", err)
+		}
+	})
+}
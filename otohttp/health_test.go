@@ -0,0 +1,83 @@
+package otohttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestServerHealthz(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	srv.Healthz().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"status":"ok","checks":{}}`)
+
+	srv.AddHealthCheck("db", func(ctx context.Context) error {
+		return nil
+	})
+	w = httptest.NewRecorder()
+	srv.Healthz().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"status":"ok","checks":{"db":"ok"}}`)
+
+	srv.AddHealthCheck("cache", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	w = httptest.NewRecorder()
+	srv.Healthz().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusServiceUnavailable)
+	is.Equal(w.Body.String(), `{"status":"failed","checks":{"cache":"connection refused","db":"ok"}}`)
+}
+
+func TestServerReadyz(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	ready := false
+	srv.AddReadyCheck("warmup", func(ctx context.Context) error {
+		if !ready {
+			return errors.New("warming up")
+		}
+		return nil
+	})
+	srv.AddHealthCheck("never", func(ctx context.Context) error {
+		return errors.New("health checks are not ready checks")
+	})
+	r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	srv.Readyz().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusServiceUnavailable)
+	is.Equal(w.Body.String(), `{"status":"failed","checks":{"warmup":"warming up"}}`)
+
+	ready = true
+	w = httptest.NewRecorder()
+	srv.Readyz().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusOK)
+}
+
+func TestServerRoutes(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.Basepath = "/api/"
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	srv.Register("Welcomer", "Welcome", h)
+	srv.Register("GreeterService", "Greet", h)
+	srv.Register("GreeterService", "GetGreetings", h)
+	is.Equal(srv.Routes(), []string{
+		"GreeterService.GetGreetings",
+		"GreeterService.Greet",
+		"Welcomer.Welcome",
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/routes", nil)
+	srv.RoutesHandler().ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"basepath":"/api/","routes":["GreeterService.GetGreetings","GreeterService.Greet","Welcomer.Welcome"]}`)
+}
@@ -0,0 +1,322 @@
+package otohttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is the error in the response when an
+// Idempotency-Key is reused with a different request body.
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request body")
+
+// ErrIdempotencyKeyInUse is returned by IdempotencyStore.Begin when
+// another request with the same key is still in progress.
+var ErrIdempotencyKeyInUse = errors.New("idempotency key in use")
+
+// IdempotencyStore stores the responses of requests made with an
+// Idempotency-Key header, so they can be replayed when the request
+// is retried.
+//
+// Methods opt in with comment metadata:
+//
+//	// CreateGreeting creates a greeting.
+//	// idempotent: true
+//	CreateGreeting(CreateGreetingRequest) CreateGreetingResponse
+type IdempotencyStore interface {
+	// Begin claims the key for a new request, returning nil.
+	// If a response has already been stored for the key, it is
+	// returned instead.
+	// If the key is claimed by a request that is still in progress,
+	// Begin returns ErrIdempotencyKeyInUse, or an error that wraps it.
+	Begin(ctx context.Context, key string) (*IdempotentResponse, error)
+	// Complete stores the response for a claimed key.
+	Complete(ctx context.Context, key string, response IdempotentResponse) error
+	// Release gives up the claim on a key without storing a response,
+	// so that the request may be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotentResponse is a response stored by an IdempotencyStore.
+// The Body is not compressed, so it can be replayed to any request.
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// RequestHash is the hex SHA-256 hash of the request body, so
+	// that a key reused with a different body is rejected instead of
+	// replayed. If empty, the response is replayed to any body.
+	RequestHash string
+}
+
+// idempotencyPollInterval is how often a duplicate request checks
+// whether the original request has completed.
+const idempotencyPollInterval = 50 * time.Millisecond
+
+// idempotent wraps next with idempotency key handling, if the method
+// has the idempotent metadata flag and there is an IdempotencyStore.
+func (s *Server) idempotent(service, method string, next http.Handler) http.Handler {
+	if s.IdempotencyStore == nil || s.Metadata(service, method)["idempotent"] != true {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get("Idempotency-Key")
+		if idempotencyKey == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := service + "." + method + ":" + idempotencyKey
+		if s.LimitKey != nil {
+			key = s.LimitKey(r) + ":" + key
+		}
+		requestHash, err := hashBody(r)
+		if err != nil {
+			s.OnErr(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		deadline := time.Now().Add(s.IdempotencyWait)
+		for {
+			stored, err := s.IdempotencyStore.Begin(ctx, key)
+			if errors.Is(err, ErrIdempotencyKeyInUse) && time.Now().Before(deadline) {
+				select {
+				case <-time.After(idempotencyPollInterval):
+					continue
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			}
+			if errors.Is(err, ErrIdempotencyKeyInUse) {
+				s.idempotencyError(w, r, http.StatusConflict, err)
+				return
+			}
+			if err != nil {
+				s.OnErr(w, r, err)
+				return
+			}
+			if stored != nil {
+				if stored.RequestHash != "" && stored.RequestHash != requestHash {
+					s.idempotencyError(w, r, http.StatusUnprocessableEntity, ErrIdempotencyKeyReused)
+					return
+				}
+				replay(w, r, *stored)
+				return
+			}
+			break
+		}
+		rec := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := s.IdempotencyStore.Release(context.Background(), key); err != nil {
+				log.Printf("failed to release idempotency key: %s\n", err)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			// don't keep server errors, the request may be retried
+			return
+		}
+		status := rec.status
+		if status == 0 {
+			// the handler wrote nothing, which net/http sends as 200
+			status = http.StatusOK
+		}
+		response, err := uncompressed(IdempotentResponse{
+			Status:      status,
+			Header:      w.Header().Clone(),
+			Body:        rec.body.Bytes(),
+			RequestHash: requestHash,
+		})
+		if err != nil {
+			log.Printf("failed to store idempotent response: %s\n", err)
+			return
+		}
+		if err := s.IdempotencyStore.Complete(context.Background(), key, response); err != nil {
+			log.Printf("failed to store idempotent response: %s\n", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyError writes an error response with the status.
+func (s *Server) idempotencyError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	errObj := struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	}
+	if err := Encode(w, r, status, errObj); err != nil {
+		log.Printf("failed to encode error: %s\n", err)
+	}
+}
+
+// hashBody gets the hex SHA-256 hash of the request body, reading as
+// much of it as Decode does, and puts the body back for the handler.
+func hashBody(r *http.Request) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("idempotency: read body: %w", err)
+	}
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readCloser reads from a Reader and closes a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// uncompressed gets the response with its body uncompressed, if it was
+// gzipped by Encode, because a retry may not accept gzip.
+func uncompressed(response IdempotentResponse) (IdempotentResponse, error) {
+	if !strings.Contains(response.Header.Get("Content-Encoding"), "gzip") {
+		return response, nil
+	}
+	gzr, err := gzip.NewReader(bytes.NewReader(response.Body))
+	if err != nil {
+		return response, err
+	}
+	body, err := io.ReadAll(gzr)
+	if err != nil {
+		return response, err
+	}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.Body = body
+	return response, nil
+}
+
+// replay writes a stored response, gzipped if the request accepts it,
+// like Encode.
+func replay(w http.ResponseWriter, r *http.Request, response IdempotentResponse) {
+	for k, v := range response.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	var out io.Writer = w
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		gzw := gzip.NewWriter(w)
+		out = gzw
+		defer gzw.Close()
+	}
+	w.WriteHeader(response.Status)
+	if _, err := out.Write(response.Body); err != nil {
+		log.Printf("failed to replay response: %s\n", err)
+	}
+}
+
+// responseRecorder is an http.ResponseWriter that keeps a copy of the
+// response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore that keeps responses
+// in memory.
+// It is suitable for a single server, or for testing.
+type MemoryIdempotencyStore struct {
+	ttl time.Duration
+
+	lock      sync.Mutex
+	entries   map[string]*idempotencyEntry
+	nextSweep time.Time
+}
+
+type idempotencyEntry struct {
+	// response is nil while the request is in progress.
+	response *IdempotentResponse
+	expires  time.Time
+}
+
+// NewMemoryIdempotencyStore makes a new MemoryIdempotencyStore that
+// keeps responses for the ttl duration.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+	}
+}
+
+// Begin claims the key, or gets the response stored for it.
+func (m *MemoryIdempotencyStore) Begin(ctx context.Context, key string) (*IdempotentResponse, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	m.sweep(now)
+	entry, ok := m.entries[key]
+	if ok && now.Before(entry.expires) {
+		if entry.response == nil {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return entry.response, nil
+	}
+	m.entries[key] = &idempotencyEntry{expires: now.Add(m.ttl)}
+	return nil, nil
+}
+
+// Complete stores the response for the key.
+func (m *MemoryIdempotencyStore) Complete(ctx context.Context, key string, response IdempotentResponse) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.entries[key] = &idempotencyEntry{
+		response: &response,
+		expires:  time.Now().Add(m.ttl),
+	}
+	return nil
+}
+
+// Release forgets the key.
+func (m *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep removes expired entries, at most once per ttl.
+func (m *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Before(m.nextSweep) {
+		return
+	}
+	for key, entry := range m.entries {
+		if !now.Before(entry.expires) {
+			delete(m.entries, key)
+		}
+	}
+	m.nextSweep = now.Add(m.ttl)
+}
@@ -0,0 +1,299 @@
+package otohttp
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestServerIdempotency(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Call", strconv.Itoa(calls))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":` + strconv.Itoa(calls) + `}`))
+	})
+	srv.Register("Service", "Create", h)
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	srv.Register("Service", "Other", h)
+	call := func(method, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service."+method, strings.NewReader(`{}`))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call("Create", "abc")
+	is.Equal(w.Code, http.StatusCreated)
+	is.Equal(w.Body.String(), `{"id":1}`)
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "")
+
+	w = call("Create", "abc")
+	is.Equal(calls, 1) // handler should not be called again
+	is.Equal(w.Code, http.StatusCreated)
+	is.Equal(w.Body.String(), `{"id":1}`)
+	is.Equal(w.Header().Get("X-Call"), "1")
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "true")
+
+	w = call("Create", "def")
+	is.Equal(w.Body.String(), `{"id":2}`)
+	w = call("Create", "")
+	is.Equal(w.Body.String(), `{"id":3}`)
+
+	// methods without the idempotent flag ignore the header
+	w = call("Other", "abc")
+	is.Equal(w.Body.String(), `{"id":4}`)
+	w = call("Other", "abc")
+	is.Equal(w.Body.String(), `{"id":5}`)
+}
+
+func TestServerIdempotencyDifferentBody(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	var calls int
+	srv.Register("Service", "Create", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var request struct{ Name string }
+		err := Decode(r, &request)
+		is.NoErr(err) // the handler still gets the whole body
+		w.Write([]byte(`{"name":"` + request.Name + `"}`))
+	})
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	call := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(body))
+		r.Header.Set("Idempotency-Key", "abc")
+		srv.ServeHTTP(w, r)
+		return w
+	}
+
+	w := call(`{"name":"Mat"}`)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"name":"Mat"}`)
+
+	w = call(`{"name":"David"}`)
+	is.Equal(calls, 1)
+	is.Equal(w.Code, http.StatusUnprocessableEntity)
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "")
+	is.True(strings.Contains(w.Body.String(), ErrIdempotencyKeyReused.Error()))
+
+	w = call(`{"name":"Mat"}`)
+	is.Equal(calls, 1)
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"name":"Mat"}`)
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "true")
+}
+
+func TestServerIdempotencyConflict(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-unblock
+		w.Write([]byte(`{"id":1}`))
+	})
+	srv.Register("Service", "Create", h)
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	call := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "abc")
+		srv.ServeHTTP(w, r)
+		return w
+	}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- call()
+	}()
+	<-started
+
+	w := call()
+	is.Equal(w.Code, http.StatusConflict)
+	is.Equal(w.Body.String(), `{"error":"idempotency key in use"}`)
+
+	// with IdempotencyWait, the duplicate waits for the response
+	srv.IdempotencyWait = time.Second
+	waited := make(chan *httptest.ResponseRecorder)
+	go func() {
+		waited <- call()
+	}()
+	time.Sleep(2 * idempotencyPollInterval)
+	close(unblock)
+	is.Equal((<-done).Body.String(), `{"id":1}`)
+	w = <-waited
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), `{"id":1}`)
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "true")
+}
+
+// wrappingStore is an IdempotencyStore that wraps the errors of its
+// store.
+type wrappingStore struct {
+	IdempotencyStore
+}
+
+func (s wrappingStore) Begin(ctx context.Context, key string) (*IdempotentResponse, error) {
+	stored, err := s.IdempotencyStore.Begin(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("begin %s: %w", key, err)
+	}
+	return stored, nil
+}
+
+func TestServerIdempotencyWrappedConflict(t *testing.T) {
+	is := is.New(t)
+	store := NewMemoryIdempotencyStore(time.Minute)
+	srv := NewServer()
+	srv.IdempotencyStore = wrappingStore{store}
+	srv.Register("Service", "Create", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	// claimed by a request in progress
+	_, err := store.Begin(context.Background(), "Service.Create:abc")
+	is.NoErr(err)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(`{}`))
+	r.Header.Set("Idempotency-Key", "abc")
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusConflict)
+}
+
+func TestServerIdempotencyServerError(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	srv.Register("Service", "Create", h)
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "abc")
+		srv.ServeHTTP(w, r)
+		is.Equal(w.Code, expected)
+	}
+	is.Equal(calls, 2) // server errors are not stored
+}
+
+func TestServerIdempotencyEmptyResponse(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	srv.Register("Service", "Create", h)
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	for _, replayed := range []string{"", "true"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "abc")
+		srv.ServeHTTP(w, r)
+		is.Equal(w.Code, http.StatusOK)
+		is.Equal(w.Body.String(), "")
+		is.Equal(w.Header().Get("Idempotent-Replayed"), replayed)
+	}
+	is.Equal(calls, 1)
+}
+
+func TestServerIdempotencyGzip(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IdempotencyStore = NewMemoryIdempotencyStore(time.Minute)
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		err := Encode(w, r, http.StatusOK, struct {
+			ID int `json:"id"`
+		}{ID: calls})
+		is.NoErr(err)
+	})
+	srv.Register("Service", "Create", h)
+	srv.RegisterMetadata("Service", "Create", `{"idempotent":true}`)
+	call := func(acceptEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Create", strings.NewReader(`{}`))
+		r.Header.Set("Idempotency-Key", "abc")
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		srv.ServeHTTP(w, r)
+		return w
+	}
+	gunzip := func(w *httptest.ResponseRecorder) string {
+		is.Equal(w.Header().Get("Content-Encoding"), "gzip")
+		gzr, err := gzip.NewReader(w.Body)
+		is.NoErr(err)
+		b, err := io.ReadAll(gzr)
+		is.NoErr(err)
+		return string(b)
+	}
+
+	w := call("gzip")
+	is.Equal(gunzip(w), `{"id":1}`)
+
+	// the retry doesn't accept gzip
+	w = call("")
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "true")
+	is.Equal(w.Header().Get("Content-Encoding"), "")
+	is.Equal(w.Body.String(), `{"id":1}`)
+
+	w = call("gzip")
+	is.Equal(w.Header().Get("Idempotent-Replayed"), "true")
+	is.Equal(gunzip(w), `{"id":1}`)
+	is.Equal(calls, 1)
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	store := NewMemoryIdempotencyStore(50 * time.Millisecond)
+	stored, err := store.Begin(ctx, "key")
+	is.NoErr(err)
+	is.Equal(stored, nil)
+	_, err = store.Begin(ctx, "key")
+	is.Equal(err, ErrIdempotencyKeyInUse)
+	err = store.Complete(ctx, "key", IdempotentResponse{Status: http.StatusOK, Body: []byte(`{}`)})
+	is.NoErr(err)
+	stored, err = store.Begin(ctx, "key")
+	is.NoErr(err)
+	is.Equal(stored.Status, http.StatusOK)
+	is.Equal(string(stored.Body), `{}`)
+
+	time.Sleep(60 * time.Millisecond)
+	stored, err = store.Begin(ctx, "key")
+	is.NoErr(err)
+	is.Equal(stored, nil) // expired
+
+	err = store.Release(ctx, "key")
+	is.NoErr(err)
+	stored, err = store.Begin(ctx, "key")
+	is.NoErr(err)
+	is.Equal(stored, nil)
+}
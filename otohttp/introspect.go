@@ -0,0 +1,38 @@
+package otohttp
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// The service and method names of the introspection route, as passed
+// to the Authorizer and used with Limit.
+const (
+	IntrospectionService = "_oto"
+	IntrospectionMethod  = "definition"
+)
+
+// RegisterDefinition sets the definition served by the introspection
+// route. The definition is the JSON encoded parser.Definition the
+// services were generated from.
+// Generated code calls RegisterDefinition when registering services.
+func (s *Server) RegisterDefinition(definitionJSON string) {
+	s.definition = json.RawMessage(definitionJSON)
+}
+
+// serveDefinition serves the registered definition, responding to
+// GET or POST requests. Requests are authorized and limited like
+// other methods, as IntrospectionService.IntrospectionMethod.
+func (s *Server) serveDefinition(w http.ResponseWriter, r *http.Request) {
+	if s.definition == nil || (r.Method != http.MethodGet && r.Method != http.MethodPost) {
+		s.NotFound.ServeHTTP(w, r)
+		return
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Encode(w, r, http.StatusOK, s.definition); err != nil {
+			log.Printf("failed to encode definition: %s\n", err)
+		}
+	})
+	s.serveLimited(w, r, IntrospectionService, IntrospectionMethod, s.authorize(IntrospectionService, IntrospectionMethod, h))
+}
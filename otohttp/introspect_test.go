@@ -0,0 +1,78 @@
+package otohttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestServerIntrospection(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.RegisterDefinition(`{
+		"packageName": "services",
+		"services": [{"name": "Service"}]
+	}`)
+
+	// disabled by default
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/oto/_oto/definition", nil)
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusNotFound)
+
+	srv.IntrospectionPath = "_oto/definition"
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(method, "/oto/_oto/definition", nil)
+		srv.ServeHTTP(w, r)
+		is.Equal(w.Code, http.StatusOK)
+		is.Equal(w.Body.String(), `{"packageName":"services","services":[{"name":"Service"}]}`)
+		is.Equal(w.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/oto/_oto/definition", nil)
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusNotFound)
+}
+
+func TestServerIntrospectionNoDefinition(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.IntrospectionPath = "_oto/definition"
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/oto/_oto/definition", nil)
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusNotFound)
+}
+
+func TestServerIntrospectionAuthorizeLimit(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.RegisterDefinition(`{"packageName": "services"}`)
+	srv.IntrospectionPath = "_oto/definition"
+	var gotService, gotMethod string
+	srv.Authorizer = AuthorizerFunc(func(r *http.Request, service, method string, metadata map[string]interface{}) error {
+		gotService, gotMethod = service, method
+		if r.Header.Get("Authorization") == "" {
+			return ErrUnauthorized
+		}
+		return nil
+	})
+	srv.Limit(IntrospectionService, IntrospectionMethod, Limit{Rate: 0.001, Burst: 1})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/oto/_oto/definition", nil)
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusUnauthorized)
+	is.Equal(gotService, IntrospectionService)
+	is.Equal(gotMethod, IntrospectionMethod)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/oto/_oto/definition", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusTooManyRequests) // the first request took the token
+}
@@ -0,0 +1,235 @@
+package otohttp
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit describes the rate and concurrency limits applied to
+// a service or method.
+//
+// In generated code, limits may be declared with comment metadata
+// on the method:
+//
+//	// Greet makes a greeting.
+//	// rateLimit: 10
+//	// rateBurst: 20
+//	// maxInFlight: 4
+//	Greet(GreetRequest) GreetResponse
+type Limit struct {
+	// Rate is the number of requests per second that are allowed.
+	// Zero means no rate limit.
+	Rate float64
+	// Burst is the maximum number of requests allowed at once before
+	// the Rate applies.
+	// Default: 1
+	Burst int
+	// MaxInFlight is the maximum number of requests that may be
+	// handled concurrently.
+	// Zero means no concurrency limit.
+	MaxInFlight int
+}
+
+// Limit sets the limits for the specified service method.
+// If method is empty, the limit is shared by every method in the
+// service.
+// If Server.LimitKey is set, limits apply separately to each caller.
+func (s *Server) Limit(service, method string, limit Limit) {
+	name := service
+	if method != "" {
+		name = service + "." + method
+	}
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+	if s.limiters == nil {
+		s.limiters = make(map[string]*limiter)
+	}
+	s.limiters[name] = newLimiter(limit)
+}
+
+// serveLimited checks the limits for the named service method, calling
+// next if the request is allowed, or responding with
+// http.StatusTooManyRequests if not.
+func (s *Server) serveLimited(w http.ResponseWriter, r *http.Request, service, method string, next http.Handler) {
+	s.limitsLock.Lock()
+	limiters := []*limiter{s.limiters[service], s.limiters[service+"."+method]}
+	s.limitsLock.Unlock()
+	var key string
+	if s.LimitKey != nil {
+		key = s.LimitKey(r)
+	}
+	var acquired []*limiter
+	defer func() {
+		for _, l := range acquired {
+			l.release(key)
+		}
+	}()
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		if !l.acquire(key) {
+			s.tooManyRequests(w, r, time.Second)
+			return
+		}
+		acquired = append(acquired, l)
+	}
+	// in flight counts are released if this rejects the request, but
+	// tokens are not, so take them last
+	if retryAfter, ok := allow(limiters, key, time.Now()); !ok {
+		s.tooManyRequests(w, r, retryAfter)
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+func (s *Server) tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	errObj := struct {
+		Error string `json:"error"`
+	}{
+		Error: http.StatusText(http.StatusTooManyRequests),
+	}
+	if err := Encode(w, r, http.StatusTooManyRequests, errObj); err != nil {
+		log.Printf("failed to encode error: %s\n", err)
+	}
+}
+
+// maxBuckets is the number of caller buckets a limiter holds. Beyond
+// it, buckets that have refilled are forgotten, and then the least
+// recently used.
+const maxBuckets = 1024
+
+// limiter applies a Limit, keeping a token bucket and an in-flight
+// count for each caller key.
+type limiter struct {
+	limit Limit
+
+	lock     sync.Mutex
+	buckets  map[string]*bucket
+	inflight map[string]int
+}
+
+func newLimiter(limit Limit) *limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &limiter{
+		limit:    limit,
+		buckets:  make(map[string]*bucket),
+		inflight: make(map[string]int),
+	}
+}
+
+// allow takes a token from the caller's bucket in each of the limiters,
+// which may be nil. If any bucket is empty, it takes none, and returns
+// false along with how long until a token will be available.
+func allow(limiters []*limiter, key string, now time.Time) (time.Duration, bool) {
+	var buckets []*bucket
+	for _, l := range limiters {
+		if l == nil || l.limit.Rate <= 0 {
+			continue
+		}
+		// limiters are always in the same order (service, then
+		// method), so locking them all can't deadlock
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		b := l.bucket(key, now)
+		if b.tokens < 1 {
+			wait := (1 - b.tokens) / l.limit.Rate
+			return time.Duration(wait * float64(time.Second)), false
+		}
+		buckets = append(buckets, b)
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return 0, true
+}
+
+// bucket gets the caller's bucket, refilled to now.
+// The lock must be held.
+func (l *limiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.forgetBuckets(now)
+		}
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(l.limit, now)
+	return b
+}
+
+// forgetBuckets removes buckets that have refilled, since they behave
+// the same as new ones. If none have, it removes the least recently
+// used bucket, so there are never more than maxBuckets.
+func (l *limiter) forgetBuckets(now time.Time) {
+	var oldestKey string
+	var oldest *bucket
+	for key, b := range l.buckets {
+		b.refill(l.limit, now)
+		if b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+			continue
+		}
+		if oldest == nil || b.last.Before(oldest.last) {
+			oldestKey, oldest = key, b
+		}
+	}
+	if len(l.buckets) >= maxBuckets && oldest != nil {
+		delete(l.buckets, oldestKey)
+	}
+}
+
+// acquire counts a request as in flight for the caller, returning
+// false if MaxInFlight has been reached.
+// Callers must call release when acquire returns true.
+func (l *limiter) acquire(key string) bool {
+	if l.limit.MaxInFlight <= 0 {
+		return true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.inflight[key] >= l.limit.MaxInFlight {
+		return false
+	}
+	l.inflight[key]++
+	return true
+}
+
+func (l *limiter) release(key string) {
+	if l.limit.MaxInFlight <= 0 {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inflight[key]--
+	if l.inflight[key] <= 0 {
+		delete(l.inflight, key)
+	}
+}
+
+// bucket is a token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.last = now
+}
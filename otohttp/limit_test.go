@@ -0,0 +1,165 @@
+package otohttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestServerLimitRate(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"greeting":"Hi Mat"}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.Register("Service", "Other", h)
+	srv.Limit("Service", "Method", Limit{Rate: 1, Burst: 2})
+	call := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service."+method, strings.NewReader(`{"name":"Mat"}`))
+		srv.ServeHTTP(w, r)
+		return w
+	}
+	is.Equal(call("Method").Code, http.StatusOK)
+	is.Equal(call("Method").Code, http.StatusOK)
+	w := call("Method")
+	is.Equal(w.Code, http.StatusTooManyRequests)
+	is.Equal(w.Header().Get("Retry-After"), "1")
+	is.Equal(w.Body.String(), `{"error":"Too Many Requests"}`)
+	is.Equal(call("Other").Code, http.StatusOK) // other methods are not limited
+}
+
+func TestServerLimitService(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.Register("Service", "Other", h)
+	srv.Limit("Service", "", Limit{Rate: 1})
+	call := func(method string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service."+method, strings.NewReader(`{}`))
+		srv.ServeHTTP(w, r)
+		return w.Code
+	}
+	is.Equal(call("Method"), http.StatusOK)
+	is.Equal(call("Other"), http.StatusTooManyRequests) // shared by the service
+}
+
+func TestServerLimitKey(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	srv.LimitKey = func(r *http.Request) string {
+		return r.Header.Get("X-API-Key")
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.Limit("Service", "Method", Limit{Rate: 1})
+	call := func(key string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Method", strings.NewReader(`{}`))
+		r.Header.Set("X-API-Key", key)
+		srv.ServeHTTP(w, r)
+		return w.Code
+	}
+	is.Equal(call("one"), http.StatusOK)
+	is.Equal(call("one"), http.StatusTooManyRequests)
+	is.Equal(call("two"), http.StatusOK)
+}
+
+func TestServerLimitMaxInFlight(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-unblock
+		w.Write([]byte(`{}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.Limit("Service", "Method", Limit{MaxInFlight: 1})
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service.Method", strings.NewReader(`{}`))
+		srv.ServeHTTP(w, r)
+		done <- w.Code
+	}()
+	<-started
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/oto/Service.Method", strings.NewReader(`{}`))
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusTooManyRequests)
+	close(unblock)
+	is.Equal(<-done, http.StatusOK)
+	// the slot is released once the first request is done
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/oto/Service.Method", strings.NewReader(`{}`))
+	srv.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusOK)
+}
+
+func TestServerLimitServiceAndMethod(t *testing.T) {
+	is := is.New(t)
+	srv := NewServer()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	srv.Register("Service", "Method", h)
+	srv.Register("Service", "Other", h)
+	srv.Limit("Service", "", Limit{Rate: 1, Burst: 2})
+	srv.Limit("Service", "Method", Limit{Rate: 1})
+	call := func(method string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/oto/Service."+method, strings.NewReader(`{}`))
+		srv.ServeHTTP(w, r)
+		return w.Code
+	}
+	is.Equal(call("Method"), http.StatusOK)
+	is.Equal(call("Method"), http.StatusTooManyRequests)
+	is.Equal(call("Method"), http.StatusTooManyRequests)
+	// requests the method limit rejected didn't use the service's tokens
+	is.Equal(call("Other"), http.StatusOK)
+	is.Equal(call("Other"), http.StatusTooManyRequests)
+}
+
+func TestLimiterRefill(t *testing.T) {
+	is := is.New(t)
+	l := newLimiter(Limit{Rate: 2, Burst: 1})
+	now := time.Now()
+	_, ok := allow([]*limiter{l}, "", now)
+	is.True(ok)
+	retryAfter, ok := allow([]*limiter{l}, "", now)
+	is.True(!ok)
+	is.Equal(retryAfter, 500*time.Millisecond)
+	_, ok = allow([]*limiter{l}, "", now.Add(500*time.Millisecond))
+	is.True(ok)
+}
+
+func TestLimiterMaxBuckets(t *testing.T) {
+	is := is.New(t)
+	l := newLimiter(Limit{Rate: 0.001})
+	now := time.Now()
+	for i := 0; i < maxBuckets*2; i++ {
+		// none of the buckets refill
+		_, ok := allow([]*limiter{l}, strconv.Itoa(i), now.Add(time.Duration(i)*time.Millisecond))
+		is.True(ok)
+	}
+	is.Equal(len(l.buckets), maxBuckets)
+	_, ok := l.buckets["0"]
+	is.True(!ok) // the least recently used was forgotten
+	_, ok = l.buckets[strconv.Itoa(maxBuckets*2-1)]
+	is.True(ok)
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -24,6 +26,41 @@ type Server struct {
 	NotFound http.Handler
 	// OnErr is called when there is an error.
 	OnErr func(w http.ResponseWriter, r *http.Request, err error)
+	// LimitKey extracts the caller key from the request, so that
+	// limits set with Limit apply to each caller separately.
+	// If nil, limits are shared by all callers.
+	LimitKey func(r *http.Request) string
+	// Authorizer, if set, authorizes each request before it is
+	// decoded.
+	Authorizer Authorizer
+	// IntrospectionPath is the path (after Basepath) of the route that
+	// serves the definition set with RegisterDefinition, like
+	// "_oto/definition".
+	// Default: "" (introspection is disabled)
+	IntrospectionPath string
+	// IdempotencyStore, if set, stores the responses of methods with
+	// the idempotent metadata flag that are called with an
+	// Idempotency-Key header, replaying them when the request is
+	// repeated.
+	// If LimitKey is set, keys are separate for each caller.
+	IdempotencyStore IdempotencyStore
+	// IdempotencyWait is how long a request waits for another request
+	// with the same Idempotency-Key to complete before getting a
+	// http.StatusConflict response.
+	// Default: 0 (no waiting)
+	IdempotencyWait time.Duration
+
+	// metadata holds the comment metadata for each "Service.Method".
+	metadata map[string]map[string]interface{}
+	// definition is the JSON definition served by the introspection
+	// route.
+	definition json.RawMessage
+
+	healthChecks []namedCheck
+	readyChecks  []namedCheck
+
+	limitsLock sync.Mutex
+	limiters   map[string]*limiter
 }
 
 // NewServer makes a new Server.
@@ -52,6 +89,10 @@ func (s *Server) Register(service, method string, h http.HandlerFunc) {
 
 // ServeHTTP serves the request.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.IntrospectionPath != "" && r.URL.Path == s.Basepath+s.IntrospectionPath {
+		s.serveDefinition(w, r)
+		return
+	}
 	if r.Method != http.MethodPost {
 		s.NotFound.ServeHTTP(w, r)
 		return
@@ -61,7 +102,18 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.NotFound.ServeHTTP(w, r)
 		return
 	}
-	h.ServeHTTP(w, r)
+	service, method := s.serviceMethod(r.URL.Path)
+	s.serveLimited(w, r, service, method, s.authorize(service, method, s.idempotent(service, method, h)))
+}
+
+// serviceMethod gets the service and method names from a route path.
+func (s *Server) serviceMethod(path string) (service, method string) {
+	name := strings.TrimPrefix(path, s.Basepath)
+	dot := strings.Index(name, ".")
+	if dot == -1 {
+		return name, ""
+	}
+	return name[:dot], name[dot+1:]
 }
 
 // Encode writes the response.
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// parseParam parses a key=value -param flag. Values that are valid
+// JSON (numbers, booleans, lists, objects and quoted strings) are
+// decoded, anything else is a string.
+func parseParam(s string) (string, interface{}, error) {
+	segs := strings.SplitN(s, "=", 2)
+	key := strings.TrimSpace(segs[0])
+	if len(segs) != 2 || key == "" {
+		return "", nil, errors.Errorf("malformed param %q (expected key=value)", s)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(segs[1]), &value); err != nil {
+		return key, segs[1], nil
+	}
+	return key, value, nil
+}
+
+// loadParamsFile reads params from a file. Files with a .json
+// extension are parsed as JSON, all others as YAML.
+func loadParamsFile(path string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".json" {
+		if err := json.Unmarshal(b, &params); err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+		return params, nil
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(b)).Decode(&params); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, path)
+	}
+	return params, nil
+}
+
+// envVarRegexp matches ${VAR} references, and $$ escapes.
+var envVarRegexp = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolate replaces ${VAR} in the strings within v with the
+// value of the environment variable, and $$ with $.
+// It is an error to reference a variable that isn't set.
+func interpolate(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		var err error
+		s := envVarRegexp.ReplaceAllStringFunc(v, func(match string) string {
+			if match == "$$" {
+				return "$"
+			}
+			name := match[2 : len(match)-1]
+			value, ok := os.LookupEnv(name)
+			if !ok && err == nil {
+				err = errors.Errorf("environment variable %s is not set", name)
+			}
+			return value
+		})
+		return s, err
+	case map[string]interface{}:
+		for key, value := range v {
+			value, err := interpolate(value)
+			if err != nil {
+				return nil, errors.Wrap(err, key)
+			}
+			v[key] = value
+		}
+		return v, nil
+	case []interface{}:
+		for i, value := range v {
+			value, err := interpolate(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+		return v, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseParam(t *testing.T) {
+	is := is.New(t)
+	for _, tc := range []struct {
+		in    string
+		key   string
+		value interface{}
+	}{
+		{"name=Oto", "name", "Oto"},
+		{"endpoint=https://example.com/oto/?a=b", "endpoint", "https://example.com/oto/?a=b"},
+		{"debug=true", "debug", true},
+		{"retries=3", "retries", float64(3)},
+		{"tags=[\"a\",\"b\"]", "tags", []interface{}{"a", "b"}},
+		{"version=\"2\"", "version", "2"},
+		{"empty=", "empty", ""},
+	} {
+		key, value, err := parseParam(tc.in)
+		is.NoErr(err)
+		is.Equal(key, tc.key)
+		is.Equal(value, tc.value) // parseParam(tc.in)
+	}
+	_, _, err := parseParam("novalue")
+	is.True(err != nil)
+	_, _, err = parseParam("=value")
+	is.True(err != nil)
+}
+
+func TestInterpolate(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("OTO_TEST_HOST", "example.com")
+	params := map[string]interface{}{
+		"endpoint": "https://${OTO_TEST_HOST}/oto/",
+		"price":    "$$5",
+		"nested": map[string]interface{}{
+			"hosts": []interface{}{"${OTO_TEST_HOST}", 1},
+		},
+		"number": 2,
+	}
+	_, err := interpolate(params)
+	is.NoErr(err)
+	is.Equal(params["endpoint"], "https://example.com/oto/")
+	is.Equal(params["price"], "$5")
+	is.Equal(params["nested"], map[string]interface{}{"hosts": []interface{}{"example.com", 1}})
+	is.Equal(params["number"], 2)
+
+	_, err = interpolate(map[string]interface{}{"endpoint": "${OTO_TEST_NOT_SET}"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "OTO_TEST_NOT_SET is not set"))
+}
+
+func TestRunParams(t *testing.T) {
+	is := is.New(t)
+	t.Setenv("OTO_TEST_HOST", "example.com")
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= params["Endpoint"] %> <%= if (params["Debug"]) { %>debug<% } %> <%= len(params["Tags"]) %> <%= params["Name"] %>`), 0666)
+	is.NoErr(err)
+	paramsPath := filepath.Join(dir, "params.yaml")
+	err = ioutil.WriteFile(paramsPath, []byte(`
+Endpoint: https://${OTO_TEST_HOST}/oto/
+Tags: [one, two, three]
+Name: from file
+`), 0666)
+	is.NoErr(err)
+	outPath := filepath.Join(dir, "out.txt")
+	var buf bytes.Buffer
+	err = run(&buf, []string{
+		"oto",
+		"-template", templatePath,
+		"-out", outPath,
+		"-params-file", paramsPath,
+		"-params", "Name:from params",
+		"-param", "Debug=true",
+		"-param", "Name=from param",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "https://example.com/oto/ debug 3 from param")
+}
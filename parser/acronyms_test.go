@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestAcronyms(t *testing.T) {
+	is := is.New(t)
+	list := Acronyms([]string{"OAuth", "SKU"}, []string{"cat", "WiFi"})
+	a := newAcronyms(list)
+	is.True(a.isAcronym("oauth"))
+	is.True(a.isAcronym("SKU"))
+	is.True(a.isAcronym("ID"))
+	is.True(!a.isAcronym("CAT"))  // removed
+	is.True(!a.isAcronym("WiFi")) // removed
+	is.Equal(len(Acronyms(nil, nil)), len(DefaultAcronyms))
+}
+
+func TestCamelizeDownAcronyms(t *testing.T) {
+	defaults := newAcronyms(DefaultAcronyms)
+	custom := newAcronyms(Acronyms([]string{"OAuth", "SKU"}, []string{"CAT"}))
+	for _, tc := range []struct {
+		a        acronyms
+		in, want string
+	}{
+		{defaults, "ModelID", "modelID"},
+		{defaults, "OAuthToken", "oAuthToken"},
+		{defaults, "SKUCode", "sKUCode"},
+		{defaults, "CAT", "cat"},
+		{custom, "OAuthToken", "oauthToken"},
+		{custom, "SKUCode", "skuCode"},
+		{custom, "ProductSKU", "productSKU"},
+		{custom, "CAT", "cAT"},
+		{custom, "ModelID", "modelID"},
+	} {
+		if got := tc.a.camelizeDown(tc.in); got != tc.want {
+			t.Errorf("camelizeDown(%q): expected %q but got %q", tc.in, tc.want, got)
+		}
+	}
+}
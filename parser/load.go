@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaVersion is the version of the Definition structure.
+// It changes whenever a change to the structure would break
+// templates rendered from a saved definition.
+const SchemaVersion = 1
+
+// LoadDefinition reads a Definition that was saved as JSON, such as
+// with oto -dump json, so templates can be rendered without the
+// Go source.
+// Definitions with a different SchemaVersion are rejected.
+func LoadDefinition(r io.Reader) (Definition, error) {
+	var def Definition
+	if err := json.NewDecoder(r).Decode(&def); err != nil {
+		return def, errors.Wrap(err, "decode definition")
+	}
+	if def.SchemaVersion == 0 {
+		return def, errors.Errorf("definition has no schemaVersion (expected %d): dump it again with this version of oto", SchemaVersion)
+	}
+	if def.SchemaVersion != SchemaVersion {
+		return def, errors.Errorf("definition schemaVersion %d is not supported (expected %d): dump it again with this version of oto", def.SchemaVersion, SchemaVersion)
+	}
+	return def, nil
+}
+
+// LoadDefinitionFile reads a Definition from a JSON file.
+// See LoadDefinition.
+func LoadDefinitionFile(path string) (Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Definition{}, err
+	}
+	defer f.Close()
+	def, err := LoadDefinition(f)
+	if err != nil {
+		return def, errors.Wrap(err, path)
+	}
+	return def, nil
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLoadDefinition(t *testing.T) {
+	is := is.New(t)
+	patterns := []string{"./testdata/services/pleasantries"}
+	parser := New(patterns...)
+	def, err := parser.Parse()
+	is.NoErr(err)
+	is.Equal(def.SchemaVersion, SchemaVersion)
+
+	b, err := json.Marshal(def)
+	is.NoErr(err)
+	loaded, err := LoadDefinition(bytes.NewReader(b))
+	is.NoErr(err)
+	is.Equal(loaded.PackageName, def.PackageName)
+	is.Equal(len(loaded.Services), len(def.Services))
+	is.Equal(loaded.Services[0].Methods[0].InputObject, def.Services[0].Methods[0].InputObject)
+	is.Equal(len(loaded.Objects), len(def.Objects))
+}
+
+func TestLoadDefinitionSchemaVersion(t *testing.T) {
+	is := is.New(t)
+	_, err := LoadDefinition(strings.NewReader(`{"packageName":"services"}`))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "no schemaVersion"))
+
+	_, err = LoadDefinition(strings.NewReader(`{"schemaVersion":999,"packageName":"services"}`))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "schemaVersion 999 is not supported"))
+
+	_, err = LoadDefinition(strings.NewReader(`not json`))
+	is.True(err != nil)
+}
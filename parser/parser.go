@@ -22,6 +22,9 @@ var ErrNotFound = errors.New("not found")
 
 // Definition describes an Oto definition.
 type Definition struct {
+	// SchemaVersion is the version of this structure, see
+	// LoadDefinition.
+	SchemaVersion int `json:"schemaVersion"`
 	// PackageName is the name of the package.
 	PackageName string `json:"packageName"`
 	// Services are the services described in this definition.
@@ -171,8 +174,17 @@ type Parser struct {
 	ExcludeInterfaces []string
 	IncludeInterfaces []string
 
+	// Acronyms are the words that are kept together in generated
+	// names like NameLowerCamel. If nil, DefaultAcronyms are used,
+	// see also the Acronyms func.
+	Acronyms []string
+
 	patterns []string
+	// acronyms is the set of Acronyms.
+	acronyms acronyms
 	def      Definition
+	// files are the Go files of the parsed packages.
+	files []string
 
 	// outputObjects marks output object names.
 	outputObjects map[string]struct{}
@@ -195,17 +207,30 @@ func New(patterns ...string) *Parser {
 // Parse parses the files specified, returning the definition.
 func (p *Parser) Parse() (Definition, error) {
 	cfg := &packages.Config{
-		Mode:  packages.NeedTypes | packages.NeedName | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedName | packages.NeedSyntax,
+		Mode:  packages.NeedTypes | packages.NeedName | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedName | packages.NeedSyntax | packages.NeedFiles,
 		Tests: false,
 	}
 	pkgs, err := packages.Load(cfg, p.patterns...)
 	if err != nil {
 		return p.def, err
 	}
+	p.def.SchemaVersion = SchemaVersion
+	p.acronyms = newAcronyms(DefaultAcronyms)
+	if p.Acronyms != nil {
+		p.acronyms = newAcronyms(p.Acronyms)
+	}
 	p.outputObjects = make(map[string]struct{})
 	p.objects = make(map[string]struct{})
 	var excludedObjectsTypeIDs []string
+	p.files = nil
+	for _, pkg := range pkgs {
+		p.files = append(p.files, pkg.GoFiles...)
+	}
 	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			// the first error is usually the cause of the others
+			return p.def, pkg.Errors[0]
+		}
 		p.docs, err = doc.NewFromFiles(pkg.Fset, pkg.Syntax, "")
 		if err != nil {
 			panic(err)
@@ -264,6 +289,12 @@ func (p *Parser) Parse() (Definition, error) {
 	return p.def, nil
 }
 
+// Files gets the Go source files of the packages that were
+// loaded by Parse.
+func (p *Parser) Files() []string {
+	return p.files
+}
+
 func (p *Parser) parseService(pkg *packages.Package, obj types.Object, interfaceType *types.Interface) (Service, error) {
 	var s Service
 	s.Name = obj.Name()
@@ -294,7 +325,7 @@ func (p *Parser) parseMethod(pkg *packages.Package, serviceName string, methodTy
 	if p.Verbose {
 		fmt.Printf("%s ", m.Name)
 	}
-	m.NameLowerCamel = camelizeDown(m.Name)
+	m.NameLowerCamel = p.acronyms.camelizeDown(m.Name)
 	m.Comment = p.commentForMethod(serviceName, m.Name)
 	var err error
 	m.Metadata, m.Comment, err = p.extractCommentMetadata(m.Comment)
@@ -401,7 +432,7 @@ func (p *Parser) parseField(pkg *packages.Package, objectName string, v *types.V
 		fmt.Printf("%s ", f.Name)
 	}
 	f.Embedded = v.Embedded()
-	f.NameLowerCamel = camelizeDown(f.Name)
+	f.NameLowerCamel = p.acronyms.camelizeDown(f.Name)
 	f.Tag = tag
 	var err error
 	f.ParsedTags, err = p.parseTags(tag)
@@ -515,7 +546,7 @@ func (p *Parser) parseTypeDecl(pkg *packages.Package, obj types.Object) (Type, e
 	}
 	t.TypeName = types.TypeString(originalTyp, resolver)
 	t.ObjectName = types.TypeString(originalTyp, func(other *types.Package) string { return "" })
-	t.ObjectNameLowerCamel = camelizeDown(t.ObjectName)
+	t.ObjectNameLowerCamel = p.acronyms.camelizeDown(t.ObjectName)
 	t.TypeID = pkgPath + "." + t.ObjectName
 	t.CleanObjectName = strings.TrimPrefix(t.ObjectName, "*")
 	t.TSType = t.CleanObjectName
@@ -568,10 +599,13 @@ func (p *Parser) addOutputFields() error {
 		NameLowerCamel: "error",
 		Comment:        "Error is string explaining what went wrong. Empty if everything was fine.",
 		Type: Type{
-			TypeName:  "string",
-			JSType:    "string",
-			SwiftType: "String",
-			TSType:    "string",
+			TypeName:           "string",
+			ObjectName:         "string",
+			CleanObjectName:    "string",
+			UnderlyingTypeName: "string",
+			JSType:             "string",
+			SwiftType:          "String",
+			TSType:             "string",
 		},
 		Metadata: map[string]interface{}{},
 		Example:  "something went wrong",
@@ -294,3 +294,24 @@ func TestInclude(t *testing.T) {
 	is.Equal(len(def.Services), 1)
 	is.Equal(def.Services[0].Name, "GreeterService")
 }
+
+func TestParseErrors(t *testing.T) {
+	is := is.New(t)
+	patterns := []string{"./testdata/broken"}
+	parser := New(patterns...)
+	parser.Verbose = testing.Verbose()
+	_, err := parser.Parse()
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "broken.go:8:8: undefined: UndefinedThing")) // error with position
+	is.Equal(len(parser.Files()), 1)
+	is.True(strings.HasSuffix(parser.Files()[0], "broken.go"))
+}
+
+func TestParseFiles(t *testing.T) {
+	is := is.New(t)
+	patterns := []string{"./testdata/services/pleasantries"}
+	parser := New(patterns...)
+	_, err := parser.Parse()
+	is.NoErr(err)
+	is.Equal(len(parser.Files()), 5)
+}
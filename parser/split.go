@@ -94,16 +94,67 @@ func Split(src string) (entries []string) {
 	return
 }
 
+// DefaultAcronyms are the words that are kept together, in the same
+// case, in generated names like NameLowerCamel.
+var DefaultAcronyms = strings.Split(`HTML,JSON,JWT,ID,UUID,SQL,ACK,ACL,ADSL,AES,ANSI,API,ARP,ATM,BGP,BSS,CAT,CCITT,CHAP,CIDR,CIR,CLI,CPE,CPU,CRC,CRT,CSMA,CMOS,DCE,DEC,DES,DHCP,DNS,DRAM,DSL,DSLAM,DTE,DMI,EHA,EIA,EIGRP,EOF,ESS,FCC,FCS,FDDI,FTP,GBIC,gbps,GEPOF,HDLC,HTTP,HTTPS,IANA,ICMP,IDF,IDS,IEEE,IETF,IMAP,IP,IPS,ISDN,ISP,kbps,LACP,LAN,LAPB,LAPF,LLC,MAC,MAN,Mbps,MC,MDF,MIB,MoCA,MPLS,MTU,NAC,NAT,NBMA,NIC,NRZ,NRZI,NVRAM,OSI,OSPF,OUI,PAP,PAT,PC,PIM,PIM,PCM,PDU,POP3,POP,POTS,PPP,PPTP,PTT,PVST,RADIUS,RAM,RARP,RFC,RIP,RLL,ROM,RSTP,RTP,RCP,SDLC,SFD,SFP,SLARP,SLIP,SMTP,SNA,SNAP,SNMP,SOF,SRAM,SSH,SSID,STP,SYN,TDM,TFTP,TIA,TOFU,UDP,URL,URI,USB,UTP,VC,VLAN,VLSM,VPN,W3C,WAN,WEP,WiFi,WPA,WWW`, ",")
+
+// Acronyms gets DefaultAcronyms with add added, and remove removed
+// (ignoring case).
+func Acronyms(add, remove []string) []string {
+	removed := make(map[string]bool)
+	for _, word := range remove {
+		removed[strings.ToUpper(word)] = true
+	}
+	var list []string
+	for _, word := range append(append([]string{}, DefaultAcronyms...), add...) {
+		if word == "" || removed[strings.ToUpper(word)] {
+			continue
+		}
+		list = append(list, word)
+	}
+	return list
+}
+
+// acronyms is a set of acronyms, keyed by their upper case form.
+type acronyms map[string]string
+
+func newAcronyms(list []string) acronyms {
+	a := make(acronyms)
+	for _, word := range list {
+		a[strings.ToUpper(word)] = word
+	}
+	return a
+}
+
+func (a acronyms) isAcronym(word string) bool {
+	_, ok := a[strings.ToUpper(word)]
+	return ok
+}
+
+// split splits the word with Split, joining any words that are
+// parts of an acronym ("O", "Auth" for OAuth).
+func (a acronyms) split(word string) []string {
+	var words []string
+	for _, word := range Split(word) {
+		if n := len(words); n > 0 && a.isAcronym(words[n-1]+word) {
+			words[n-1] += word
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
 // camelizeDown converts a name or other string into a camel case
 // version with the first letter lowercase. "ModelID" becomes "modelID".
-func camelizeDown(word string) string {
-	if isAcronym(word) {
+func (a acronyms) camelizeDown(word string) string {
+	if a.isAcronym(word) {
 		// entire word is an acronym
 		return strings.ToLower(word)
 	}
-	words := Split(word)
+	words := a.split(word)
 	for i := range words {
-		if isAcronym(words[i]) {
+		if a.isAcronym(words[i]) {
 			if i == 0 {
 				words[i] = strings.ToLower(words[i])
 			} else {
@@ -114,14 +165,3 @@ func camelizeDown(word string) string {
 	word = strings.Join(words, "")
 	return strings.ToLower(word[:1]) + word[1:]
 }
-
-func isAcronym(word string) bool {
-	for _, ac := range baseAcronyms {
-		if strings.EqualFold(ac, word) {
-			return true
-		}
-	}
-	return false
-}
-
-var baseAcronyms = strings.Split(`HTML,JSON,JWT,ID,UUID,SQL,ACK,ACL,ADSL,AES,ANSI,API,ARP,ATM,BGP,BSS,CAT,CCITT,CHAP,CIDR,CIR,CLI,CPE,CPU,CRC,CRT,CSMA,CMOS,DCE,DEC,DES,DHCP,DNS,DRAM,DSL,DSLAM,DTE,DMI,EHA,EIA,EIGRP,EOF,ESS,FCC,FCS,FDDI,FTP,GBIC,gbps,GEPOF,HDLC,HTTP,HTTPS,IANA,ICMP,IDF,IDS,IEEE,IETF,IMAP,IP,IPS,ISDN,ISP,kbps,LACP,LAN,LAPB,LAPF,LLC,MAC,MAN,Mbps,MC,MDF,MIB,MoCA,MPLS,MTU,NAC,NAT,NBMA,NIC,NRZ,NRZI,NVRAM,OSI,OSPF,OUI,PAP,PAT,PC,PIM,PIM,PCM,PDU,POP3,POP,POTS,PPP,PPTP,PTT,PVST,RADIUS,RAM,RARP,RFC,RIP,RLL,ROM,RSTP,RTP,RCP,SDLC,SFD,SFP,SLARP,SLIP,SMTP,SNA,SNAP,SNMP,SOF,SRAM,SSH,SSID,STP,SYN,TDM,TFTP,TIA,TOFU,UDP,URL,URI,USB,UTP,VC,VLAN,VLSM,VPN,W3C,WAN,WEP,WiFi,WPA,WWW`, ",")
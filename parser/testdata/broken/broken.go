@@ -0,0 +1,11 @@
+package broken
+
+type BrokenService interface {
+	Break(BreakRequest) BreakResponse
+}
+
+type BreakRequest struct {
+	Thing UndefinedThing
+}
+
+type BreakResponse struct{}
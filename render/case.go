@@ -0,0 +1,92 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// words splits a name, in any case, into its words. Separators like
+// spaces, underscores and hyphens are removed, and words that Split
+// separates within a plural acronym ("I", "Ds") are joined.
+func (a acronyms) words(s string) []string {
+	var words []string
+	for _, word := range a.split(s) {
+		r, _ := utf8.DecodeRuneInString(word)
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		if n := len(words); n > 0 {
+			joined := words[n-1] + word
+			if strings.HasSuffix(joined, "s") && a.isAcronym(strings.TrimSuffix(joined, "s")) {
+				words[n-1] = joined
+				continue
+			}
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// snakeCase converts a name to snake_case. "ModelID" becomes "model_id".
+func (a acronyms) snakeCase(s string) string {
+	return a.joinWords(s, "_", strings.ToLower)
+}
+
+// screamingSnakeCase converts a name to SCREAMING_SNAKE_CASE.
+// "ModelID" becomes "MODEL_ID".
+func (a acronyms) screamingSnakeCase(s string) string {
+	return a.joinWords(s, "_", strings.ToUpper)
+}
+
+// kebabCase converts a name to kebab-case. "ModelID" becomes "model-id".
+func (a acronyms) kebabCase(s string) string {
+	return a.joinWords(s, "-", strings.ToLower)
+}
+
+// titleCase converts a name to Title Case, spelling acronyms the way
+// they are in the list. "modelID" becomes "Model ID".
+func (a acronyms) titleCase(s string) string {
+	return a.joinWords(s, " ", func(word string) string {
+		if acronym, ok := a.canonicalAcronym(word); ok {
+			return acronym
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(r)) + strings.ToLower(word[size:])
+	})
+}
+
+// pluralize gets the plural form of a name. "Greeting" becomes
+// "Greetings".
+func pluralize(s string) string {
+	return defaultRuleset.Pluralize(s)
+}
+
+// singularize gets the singular form of a name. "Greetings" becomes
+// "Greeting".
+func singularize(s string) string {
+	return defaultRuleset.Singularize(s)
+}
+
+func (a acronyms) joinWords(s, sep string, fn func(string) string) string {
+	words := a.words(s)
+	for i := range words {
+		words[i] = fn(words[i])
+	}
+	return strings.Join(words, sep)
+}
+
+// canonicalAcronym gets the acronym as it is spelled in the list,
+// keeping a plural s.
+func (a acronyms) canonicalAcronym(word string) (string, bool) {
+	plural := ""
+	// a lower case s after upper case letters is a plural, "IDs"
+	// is more likely to be plural ID than IDS
+	if base := strings.TrimSuffix(word, "s"); base != word && base != "" && base == strings.ToUpper(base) && a.isAcronym(base) {
+		word, plural = base, "s"
+	}
+	if acronym, ok := a[strings.ToUpper(word)]; ok {
+		return acronym + plural, true
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+)
+
+func TestCase(t *testing.T) {
+	for _, tc := range []struct {
+		in, snake, screaming, kebab, title string
+	}{
+		{"CamelsAreGreat", "camels_are_great", "CAMELS_ARE_GREAT", "camels-are-great", "Camels Are Great"},
+		{"ModelID", "model_id", "MODEL_ID", "model-id", "Model ID"},
+		{"modelID", "model_id", "MODEL_ID", "model-id", "Model ID"},
+		{"HTTPServer", "http_server", "HTTP_SERVER", "http-server", "HTTP Server"},
+		{"PreviewHTML", "preview_html", "PREVIEW_HTML", "preview-html", "Preview HTML"},
+		{"UserIDs", "user_ids", "USER_IDS", "user-ids", "User IDs"},
+		{"POP3Server", "pop3_server", "POP3_SERVER", "pop3-server", "POP3 Server"},
+		{"WiFiNetwork", "wifi_network", "WIFI_NETWORK", "wifi-network", "WiFi Network"},
+		{"already_snake_case", "already_snake_case", "ALREADY_SNAKE_CASE", "already-snake-case", "Already Snake Case"},
+		{"kebab-case name", "kebab_case_name", "KEBAB_CASE_NAME", "kebab-case-name", "Kebab Case Name"},
+		{"ID", "id", "ID", "id", "ID"},
+		{"", "", "", "", ""},
+	} {
+		if got := defaultAcronyms.snakeCase(tc.in); got != tc.snake {
+			t.Errorf("snakeCase(%q): expected %q but got %q", tc.in, tc.snake, got)
+		}
+		if got := defaultAcronyms.screamingSnakeCase(tc.in); got != tc.screaming {
+			t.Errorf("screamingSnakeCase(%q): expected %q but got %q", tc.in, tc.screaming, got)
+		}
+		if got := defaultAcronyms.kebabCase(tc.in); got != tc.kebab {
+			t.Errorf("kebabCase(%q): expected %q but got %q", tc.in, tc.kebab, got)
+		}
+		if got := defaultAcronyms.titleCase(tc.in); got != tc.title {
+			t.Errorf("titleCase(%q): expected %q but got %q", tc.in, tc.title, got)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	is := is.New(t)
+	is.Equal(pluralize("Greeting"), "Greetings")
+	is.Equal(pluralize("Category"), "Categories")
+	is.Equal(singularize("Greetings"), "Greeting")
+	is.Equal(singularize("Categories"), "Category")
+}
+
+func TestCaseHelpers(t *testing.T) {
+	is := is.New(t)
+	def := parser.Definition{PackageName: "GreeterService"}
+	s, err := Render(`<%= snake_case(def.PackageName) %> <%= screaming_snake_case(def.PackageName) %> <%= kebab_case(def.PackageName) %> <%= title_case(def.PackageName) %> <%= pluralize(def.PackageName) %>`, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "greeter_service GREETER_SERVICE greeter-service Greeter Service GreeterServices")
+	s, err = Text.Render(`{{ snake_case .def.PackageName }} {{ .def.PackageName | pluralize | singularize }}`, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "greeter_service GreeterService")
+}
+
+func TestAcronymOptions(t *testing.T) {
+	is := is.New(t)
+	def := parser.Definition{PackageName: "OAuthTokenSKU"}
+	template := `{{ camelize_down .def.PackageName }} {{ snake_case .def.PackageName }} {{ title_case .def.PackageName }}`
+	s, err := Text.Render(template, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "oAuthTokenSKU o_auth_token_sku O Auth Token Sku")
+	engine := TextEngine{Options{Acronyms: parser.Acronyms([]string{"OAuth", "SKU"}, nil)}}
+	s, err = engine.Render(template, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "oauthTokenSKU oauth_token_sku OAuth Token SKU")
+}
@@ -0,0 +1,224 @@
+package render
+
+import (
+	"bytes"
+	"path/filepath"
+	"text/template"
+
+	"github.com/gobuffalo/plush/v4"
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+)
+
+// Engine renders templates.
+type Engine interface {
+	// Render renders the template using the Definition and
+	// params.
+	Render(template string, def parser.Definition, params map[string]interface{}) (string, error)
+}
+
+// Plush renders plush templates, with the default Options.
+var Plush Engine = PlushEngine{}
+
+// Text renders text/template templates, with the default Options.
+var Text Engine = TextEngine{}
+
+// Options configure an Engine.
+type Options struct {
+	// Includes are where partials and libraries are found.
+	Includes Includes
+	// Acronyms are the words that naming helpers keep together.
+	// If nil, parser.DefaultAcronyms are used.
+	Acronyms []string
+	// TypeMaps are used by type_for, merged over the
+	// DefaultTypeMaps for each language.
+	TypeMaps map[string]TypeMap
+	// Name is the template name used in errors, like its filename.
+	Name string
+	// Strict makes missing params an error: params["key"] lookups in
+	// plush templates, and missing map keys in text/template templates.
+	Strict bool
+	// Data are more variables for the template, like the service
+	// when rendering a file for each service.
+	Data map[string]interface{}
+}
+
+// NewEngine gets the Engine with the name: plush or text.
+func NewEngine(name string, options Options) (Engine, error) {
+	switch name {
+	case "plush":
+		return PlushEngine{Options: options}, nil
+	case "text":
+		return TextEngine{Options: options}, nil
+	}
+	return nil, errors.Errorf("unknown engine %q (use plush or text)", name)
+}
+
+// EngineForFile gets the Engine for the template file, based on its
+// extension: .tmpl and .gotmpl files use TextEngine, all others
+// (including .plush) use PlushEngine.
+func EngineForFile(filename string, options Options) Engine {
+	switch filepath.Ext(filename) {
+	case ".tmpl", ".gotmpl":
+		return TextEngine{Options: options}
+	}
+	return PlushEngine{Options: options}
+}
+
+// helpers gets the functions available to templates, by name.
+func (o Options) helpers() map[string]interface{} {
+	a := defaultAcronyms
+	if o.Acronyms != nil {
+		a = newAcronyms(o.Acronyms)
+	}
+	typeMaps := o.typeMaps()
+	return map[string]interface{}{
+		"type_for": func(lang string, t parser.Type) (string, error) {
+			return typeFor(typeMaps, lang, t)
+		},
+		"camelize_down":        a.camelizeDown,
+		"camelize_up":          a.camelizeUp,
+		"snake_case":           a.snakeCase,
+		"screaming_snake_case": a.screamingSnakeCase,
+		"kebab_case":           a.kebabCase,
+		"title_case":           a.titleCase,
+		"pluralize":            pluralize,
+		"singularize":          singularize,
+		"json":                 toJSONHelper,
+		"format_comment_line":  formatCommentLine,
+		"format_comment_text":  formatCommentText,
+		"format_comment_html":  formatCommentHTML,
+		"indent":               indent,
+		"format_tags":          formatTags,
+		"go_string":            goString,
+	}
+}
+
+// PlushEngine renders github.com/gobuffalo/plush templates, where
+// def, params and the helpers are available as variables:
+//
+//	<%= camelize_down(def.PackageName) %>
+//
+// Partials are rendered with the plush partial helper, and have
+// access to the same variables along with the data passed to them:
+//
+//	<%= partial("objects.plush", {"objects": def.Objects}) %>
+type PlushEngine struct {
+	Options
+}
+
+// Render renders the template. Errors are *Error values, which say
+// where in the template they happened.
+func (e PlushEngine) Render(template string, def parser.Definition, params map[string]interface{}) (string, error) {
+	t, err := parsePlush(template)
+	if err != nil {
+		return "", plushError(e.Name, template, err, nil)
+	}
+	if e.Strict {
+		if err := t.checkParams(e.Name, template, params); err != nil {
+			return "", err
+		}
+	}
+	ctx := plush.NewContext()
+	for name, fn := range e.helpers() {
+		ctx.Set(name, fn)
+	}
+	for name, value := range e.Data {
+		ctx.Set(name, value)
+	}
+	ctx.Set("def", def)
+	ctx.Set("params", params)
+	ctx.Set("partialFeeder", e.Includes.read)
+	// functions the libraries define with let are kept in ctx
+	for _, library := range e.Includes.Libraries {
+		src, err := e.Includes.read(library)
+		if err != nil {
+			return "", newError(e.Name, template, err, 0, 0, nil)
+		}
+		if _, err := plush.Render(src, ctx); err != nil {
+			return "", newError(e.Name, template, errors.Wrapf(err, "library %s", library), 0, 0, nil)
+		}
+	}
+	s, err := plush.Render(template, ctx)
+	if err != nil {
+		return "", plushError(e.Name, template, err, t.loops)
+	}
+	return s, nil
+}
+
+// TextEngine renders text/template templates. The data is a map with
+// def and params, and the helpers are available as functions:
+//
+//	{{ camelize_down .def.PackageName }}
+//
+// Partials are rendered with the include function, which returns the
+// output so it can be piped:
+//
+//	{{ include "objects.tmpl" .def.Objects }}
+type TextEngine struct {
+	Options
+}
+
+// textName is the name of the template TextEngine renders.
+const textName = "oto"
+
+// Render renders the template. Errors are *Error values, which say
+// where in the template they happened.
+func (e TextEngine) Render(src string, def parser.Definition, params map[string]interface{}) (string, error) {
+	tpl, err := e.parse(textName, src)
+	if err != nil {
+		return "", textError(e.Name, textName, src, err, nil)
+	}
+	data := make(map[string]interface{})
+	for name, value := range e.Data {
+		data[name] = value
+	}
+	data["def"] = def
+	data["params"] = params
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", textError(e.Name, textName, src, err, tpl.Tree)
+	}
+	return buf.String(), nil
+}
+
+// parse parses the template, along with the libraries so that the
+// templates they define can be used.
+func (e TextEngine) parse(name, src string) (*template.Template, error) {
+	funcs := e.helpers()
+	funcs["include"] = e.include
+	tpl := template.New(name).Funcs(funcs)
+	if e.Strict {
+		tpl.Option("missingkey=error")
+	}
+	for _, library := range e.Includes.Libraries {
+		librarySrc, err := e.Includes.read(library)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tpl.New(library).Parse(librarySrc); err != nil {
+			return nil, errors.Wrapf(err, "library %s", library)
+		}
+	}
+	if _, err := tpl.Parse(src); err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// include renders the partial with the data.
+func (e TextEngine) include(name string, data interface{}) (string, error) {
+	src, err := e.Includes.read(name)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := e.parse(name, src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
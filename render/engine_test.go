@@ -0,0 +1,58 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+)
+
+func TestTextEngine(t *testing.T) {
+	is := is.New(t)
+	def := parser.Definition{
+		PackageName: "services",
+		Services: []parser.Service{
+			{
+				Name:    "GreeterService",
+				Comment: "GreeterService is polite & friendly.",
+				Methods: []parser.Method{{Name: "Greet"}, {Name: "GetGreetings"}},
+			},
+		},
+	}
+	params := map[string]interface{}{"Description": "<Things>"}
+	s, err := Text.Render(`package {{ .def.PackageName }}
+// {{ .params.Description }}
+{{ range .def.Services }}{{ format_comment_text .Comment }}type {{ .Name }} interface {
+{{- range .Methods }}
+	{{ camelize_down .Name }}() {{ format_tags "json:\"x\"" }}
+{{- end }}
+}
+{{ end }}const name = {{ go_string .def.PackageName }}`, def, params)
+	is.NoErr(err)
+	is.Equal(s, "package services\n// <Things>\n// GreeterService is polite & friendly.\ntype GreeterService interface {\n\tgreet() `json:\"x\"`\n\tgetGreetings() `json:\"x\"`\n}\nconst name = \"services\"")
+
+	_, err = Text.Render(`{{ .def.PackageName `, def, nil)
+	is.True(err != nil)
+}
+
+func TestNewEngine(t *testing.T) {
+	is := is.New(t)
+	options := Options{Includes: Includes{Paths: []string{"templates"}}}
+	engine, err := NewEngine("plush", options)
+	is.NoErr(err)
+	is.Equal(engine.(PlushEngine).Options, options)
+	engine, err = NewEngine("text", options)
+	is.NoErr(err)
+	is.Equal(engine.(TextEngine).Options, options)
+	_, err = NewEngine("mustache", options)
+	is.True(err != nil)
+
+	_, ok := EngineForFile("server.go.plush", options).(PlushEngine)
+	is.True(ok)
+	_, ok = EngineForFile("server.go.tmpl", options).(TextEngine)
+	is.True(ok)
+	_, ok = EngineForFile("server.go.gotmpl", options).(TextEngine)
+	is.True(ok)
+	_, ok = EngineForFile("server.go", options).(PlushEngine)
+	is.True(ok)
+}
@@ -0,0 +1,365 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/gobuffalo/plush/v4/ast"
+	plushparser "github.com/gobuffalo/plush/v4/parser"
+	"github.com/pkg/errors"
+)
+
+// Error is an error rendering a template, with where it happened.
+type Error struct {
+	// Name is the template name, from Options.Name.
+	Name string
+	// Line and Column are where the error happened in the template,
+	// or zero if unknown. Plush only reports lines.
+	Line, Column int
+	// Snippet is the template source around Line.
+	Snippet string
+	// Loops are the loops of the template that the error happened in,
+	// outermost first, like "for (method) in service.Methods". Plush
+	// only reports lines, so every loop on the line is included.
+	Loops []string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Name != "" {
+		b.WriteString(e.Name)
+	} else {
+		b.WriteString("template")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	if e.Column > 0 {
+		fmt.Fprintf(&b, ":%d", e.Column)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	if len(e.Loops) > 0 {
+		b.WriteString("\ninside ")
+		b.WriteString(strings.Join(e.Loops, ", "))
+	}
+	if e.Snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSuffix(e.Snippet, "\n"))
+	}
+	return b.String()
+}
+
+// Cause gets the underlying error.
+func (e *Error) Cause() error { return e.Err }
+
+// Unwrap gets the underlying error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError makes an Error for the line and column of the source.
+func newError(name, src string, err error, line, column int, loops []string) *Error {
+	return &Error{
+		Name:    name,
+		Line:    line,
+		Column:  column,
+		Snippet: snippet(src, line, column),
+		Loops:   loops,
+		Err:     err,
+	}
+}
+
+// snippet gets the lines of src around line, marking the line (and
+// column, if known).
+func snippet(src string, line, column int) string {
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	first, last := line-1, line+1
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", marker, width, n, lines[n-1])
+		if n == line && column > 0 && column <= len(lines[n-1])+1 {
+			// keep tabs so the caret lines up
+			indent := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+				return ' '
+			}, lines[n-1][:column-1])
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", indent)
+		}
+	}
+	return b.String()
+}
+
+// loop is a loop in a template, from where it starts to where its last
+// node ends: lines for plush, and offsets for text/template.
+type loop struct {
+	start, end int
+	// desc describes the loop, like "for (method) in service.Methods".
+	desc string
+}
+
+// loopsAt describes the loops that contain the position, outermost
+// first.
+func loopsAt(loops []loop, pos int) []string {
+	var descs []string
+	for _, l := range loops {
+		if pos >= l.start && pos <= l.end {
+			descs = append(descs, l.desc)
+		}
+	}
+	return descs
+}
+
+// plushLineRegexp matches the line plush puts at the start of its
+// errors.
+var plushLineRegexp = regexp.MustCompile(`(?s)^line (\d+): (.*)$`)
+
+// plushError makes an Error from a plush error. Errors in partials are
+// reported at the line that includes the partial.
+func plushError(name, src string, err error, loops []loop) *Error {
+	match := plushLineRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return newError(name, src, err, 0, 0, nil)
+	}
+	line, _ := strconv.Atoi(match[1])
+	return newError(name, src, errors.New(match[2]), line, 0, loopsAt(loops, line))
+}
+
+// plushTemplate is what the parsed plush template says about errors it
+// might have.
+type plushTemplate struct {
+	loops  []loop
+	params []plushParam
+}
+
+// plushParam is a params["key"] lookup in a plush template.
+type plushParam struct {
+	key  string
+	line int
+}
+
+// parsePlush parses the plush template to find its loops and the params it
+// uses.
+func parsePlush(src string) (*plushTemplate, error) {
+	program, err := plushparser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	t := &plushTemplate{}
+	for _, statement := range program.Statements {
+		t.walk(statement)
+	}
+	return t, nil
+}
+
+// walk walks the plush AST, finding loops and params, and returns the last
+// line of node.
+func (t *plushTemplate) walk(node ast.Node) int {
+	if node == nil {
+		return 0
+	}
+	end := node.T().LineNumber
+	walk := func(nodes ...ast.Node) {
+		for _, n := range nodes {
+			if line := t.walk(n); line > end {
+				end = line
+			}
+		}
+	}
+	switch n := node.(type) {
+	case *ast.ForExpression:
+		id := len(t.loops)
+		desc := "for (" + n.ValueName + ") in " + n.Iterable.String()
+		if n.KeyName != "" && n.KeyName != "_" {
+			desc = "for (" + n.KeyName + ", " + n.ValueName + ") in " + n.Iterable.String()
+		}
+		t.loops = append(t.loops, loop{start: end, desc: desc})
+		walk(n.Iterable, block(n.Block))
+		t.loops[id].end = end
+	case *ast.BlockStatement:
+		for _, statement := range n.Statements {
+			walk(statement)
+		}
+	case *ast.ExpressionStatement:
+		walk(n.Expression)
+	case *ast.ReturnStatement:
+		walk(n.ReturnValue)
+	case *ast.LetStatement:
+		walk(n.Value)
+	case *ast.AssignExpression:
+		walk(n.Value)
+	case *ast.IfExpression:
+		walk(n.Condition, block(n.Block))
+		for _, elseIf := range n.ElseIf {
+			walk(elseIf.Condition, block(elseIf.Block))
+		}
+		walk(block(n.ElseBlock))
+	case *ast.CallExpression:
+		walk(n.Callee, n.Function)
+		for _, arg := range n.Arguments {
+			walk(arg)
+		}
+		walk(block(n.Block), block(n.ElseBlock))
+	case *ast.FunctionLiteral:
+		walk(block(n.Block))
+	case *ast.IndexExpression:
+		left, ok := n.Left.(*ast.Identifier)
+		index, isString := n.Index.(*ast.StringLiteral)
+		if ok && isString && left.Callee == nil && left.Value == "params" && n.Value == nil {
+			t.params = append(t.params, plushParam{key: index.Value, line: n.Token.LineNumber})
+		}
+		walk(n.Left, n.Index, n.Value, n.Callee)
+	case *ast.InfixExpression:
+		walk(n.Left, n.Right)
+	case *ast.PrefixExpression:
+		walk(n.Right)
+	case *ast.ArrayLiteral:
+		for _, element := range n.Elements {
+			walk(element)
+		}
+	case *ast.HashLiteral:
+		for _, key := range n.Order {
+			walk(key, n.Pairs[key])
+		}
+	}
+	return end
+}
+
+// block gets the block as a node, so that a nil block is a nil node.
+func block(b *ast.BlockStatement) ast.Node {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// checkParams returns an error for the first params["key"] lookup
+// for a key that is not in params.
+func (t *plushTemplate) checkParams(name, src string, params map[string]interface{}) error {
+	for _, p := range t.params {
+		if _, ok := params[p.key]; !ok {
+			return newError(name, src, errors.Errorf("params[%q] is not set", p.key), p.line, 0, nil)
+		}
+	}
+	return nil
+}
+
+// textLineRegexp matches the position text/template puts at the start
+// of its errors, for the template name.
+func textLineRegexp(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)^template: ` + regexp.QuoteMeta(name) + `:(\d+)(?::(\d+))?: (.*)$`)
+}
+
+// textError makes an Error from a text/template error in the template
+// with the name, which was parsed into tree (or nil).
+func textError(name, templateName, src string, err error, tree *parse.Tree) *Error {
+	match := textLineRegexp(templateName).FindStringSubmatch(err.Error())
+	if match == nil {
+		return newError(name, src, err, 0, 0, nil)
+	}
+	line, _ := strconv.Atoi(match[1])
+	column := 0
+	if match[2] != "" {
+		// text/template columns start at zero
+		column, _ = strconv.Atoi(match[2])
+		column++
+	}
+	var loops []string
+	if tree != nil && column > 0 {
+		loops = loopsAt(textLoops(tree), offset(src, line, column))
+	}
+	return newError(name, src, errors.New(match[3]), line, column, loops)
+}
+
+// offset gets the offset in src of the line and column.
+func offset(src string, line, column int) int {
+	pos := 0
+	for n := 1; n < line; n++ {
+		i := strings.IndexByte(src[pos:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		pos += i + 1
+	}
+	return pos + column - 1
+}
+
+// textLoops finds the range loops in the tree.
+func textLoops(tree *parse.Tree) []loop {
+	var loops []loop
+	var walk func(node parse.Node) int
+	walk = func(node parse.Node) int {
+		end := int(node.Position())
+		max := func(list *parse.ListNode) {
+			if list == nil {
+				return
+			}
+			for _, child := range list.Nodes {
+				if pos := walk(child); pos > end {
+					end = pos
+				}
+			}
+		}
+		switch n := node.(type) {
+		case *parse.TextNode:
+			end += len(n.Text)
+		case *parse.ActionNode:
+			if pos := walk(n.Pipe); pos > end {
+				end = pos
+			}
+		case *parse.PipeNode:
+			for _, cmd := range n.Cmds {
+				if pos := walk(cmd); pos > end {
+					end = pos
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				if pos := walk(arg); pos > end {
+					end = pos
+				}
+			}
+		case *parse.ListNode:
+			max(n)
+		case *parse.IfNode:
+			max(n.List)
+			max(n.ElseList)
+		case *parse.WithNode:
+			max(n.List)
+			max(n.ElseList)
+		case *parse.RangeNode:
+			id := len(loops)
+			loops = append(loops, loop{start: int(n.Pos), desc: "range " + n.Pipe.String()})
+			max(n.List)
+			loops[id].end = end
+			max(n.ElseList)
+		default:
+			// an argument, like .Name
+			end += len(node.String())
+		}
+		return end
+	}
+	if tree.Root != nil {
+		walk(tree.Root)
+	}
+	return loops
+}
@@ -0,0 +1,175 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+)
+
+var errorsDef = parser.Definition{
+	Services: []parser.Service{
+		{Name: "GreeterService", Methods: []parser.Method{{Name: "Greet"}, {Name: "GetGreetings"}}},
+		{Name: "Welcomer", Methods: []parser.Method{{Name: "Welcome"}}},
+	},
+}
+
+func TestPlushError(t *testing.T) {
+	is := is.New(t)
+	engine := PlushEngine{Options: Options{Name: "services.plush"}}
+	src := `<%= for (service) in def.Services { %><%= for (method) in service.Methods { %>
+<%= if (method.Name == "Welcome") { %><%= method.Nope %><% } %>
+<% } %><% } %>
+`
+	_, err := engine.Render(src, errorsDef, nil)
+	is.True(err != nil)
+	var renderErr *Error
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"for (service) in def.Services", "for (method) in service.Methods"})
+	is.Equal(err.Error(), `services.plush:2: 'method' does not have a field or method named 'Nope' (method.Nope)
+inside for (service) in def.Services, for (method) in service.Methods
+  1 | <%= for (service) in def.Services { %><%= for (method) in service.Methods { %>
+> 2 | <%= if (method.Name == "Welcome") { %><%= method.Nope %><% } %>
+  3 | <% } %><% } %>`)
+
+	_, err = engine.Render("ok\n<%= nope( %>", errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string(nil))
+}
+
+func TestPlushErrorLoops(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"_loop.plush": "<%= for (method) in service.Methods { %>\n<%= method.Nope %>\n<% } %>",
+	})
+	engine := PlushEngine{Options: Options{Includes: Includes{Paths: []string{dir}}}}
+	var renderErr *Error
+
+	// key and value loops
+	_, err := engine.Render(`<%= for (i, service) in def.Services { %><%= i %>:<%= service.Name %>
+<%= service.Nope %>
+<% } %>`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"for (i, service) in def.Services"})
+
+	// nested loops on one line, after one that has ended
+	_, err = engine.Render(`<%= for (x) in [1, 2] { %><%= x %><% } %>
+<%= for (service) in def.Services { %><%= for (method) in service.Methods { %><%= method.Nope %><% } %><% } %>`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"for (service) in def.Services", "for (method) in service.Methods"})
+
+	// errors in partials are at the line that includes them, inside
+	// its loops but not the partial's
+	_, err = engine.Render(`<%= for (service) in def.Services { %>
+<%= partial("_loop.plush", {"service": service}) %>
+<% } %>`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"for (service) in def.Services"})
+	is.True(strings.Contains(renderErr.Err.Error(), "'method' does not have a field or method named 'Nope'"))
+
+	// errors outside loops
+	_, err = engine.Render(`<%= def.Nope %>
+<%= for (x) in [1, 2] { %>
+<%= x %>
+<% } %>`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 1)
+	is.Equal(renderErr.Loops, []string(nil))
+}
+
+func TestPlushStrict(t *testing.T) {
+	is := is.New(t)
+	src := "<%= params[\"Name\"] %>\n<%= params[\"Missing\"] %>"
+	params := map[string]interface{}{"Name": "oto"}
+	s, err := Plush.Render(src, errorsDef, params)
+	is.NoErr(err)
+	is.Equal(s, "oto\n")
+	_, err = PlushEngine{Options: Options{Strict: true}}.Render(src, errorsDef, params)
+	is.True(err != nil)
+	var renderErr *Error
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Err.Error(), `params["Missing"] is not set`)
+}
+
+func TestTextError(t *testing.T) {
+	is := is.New(t)
+	engine := TextEngine{Options: Options{Name: "services.tmpl"}}
+	src := `{{ range .def.Services }}{{ range .Methods }}
+	{{ if eq .Name "Welcome" }}{{ .Nope }}{{ end }}
+{{ end }}{{ end }}
+`
+	_, err := engine.Render(src, errorsDef, nil)
+	is.True(err != nil)
+	var renderErr *Error
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Column, 32)
+	is.Equal(renderErr.Loops, []string{"range .def.Services", "range .Methods"})
+	is.Equal(renderErr.Snippet, `  1 | {{ range .def.Services }}{{ range .Methods }}
+> 2 | 	{{ if eq .Name "Welcome" }}{{ .Nope }}{{ end }}
+    | 	                              ^
+  3 | {{ end }}{{ end }}
+`)
+
+	s, err := engine.Render(`{{ range $i, $s := .def.Services }}{{ $i }}:{{ $s.Name }} {{ end }}`, errorsDef, nil)
+	is.NoErr(err)
+	is.Equal(s, "0:GreeterService 1:Welcomer ")
+
+	_, err = engine.Render("ok\n{{ .def.Name ", errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+}
+
+func TestTextErrorLoops(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"_loop.tmpl": "{{ range .Methods }}\n{{ .Nope }}\n{{ end }}",
+	})
+	engine := TextEngine{Options: Options{Includes: Includes{Paths: []string{dir}}}}
+	var renderErr *Error
+
+	_, err := engine.Render(`{{ range $i, $s := .def.Services }}{{ $i }}
+{{ $s.Nope }}{{ end }}`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"range $i, $s := .def.Services"})
+
+	// columns tell loops on one line apart
+	_, err = engine.Render(`{{ range .def.Services }}{{ .Name }}{{ end }}{{ range .def.Services }}{{ range .Methods }}{{ .Nope }}{{ end }}{{ end }}`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Loops, []string{"range .def.Services", "range .Methods"})
+
+	_, err = engine.Render(`{{ range .def.Services }}
+{{ include "_loop.tmpl" . }}
+{{ end }}`, errorsDef, nil)
+	is.True(errors.As(err, &renderErr))
+	is.Equal(renderErr.Line, 2)
+	is.Equal(renderErr.Loops, []string{"range .def.Services"})
+}
+
+func TestTextStrict(t *testing.T) {
+	is := is.New(t)
+	src := "{{ .params.Missing }}"
+	s, err := Text.Render(src, errorsDef, map[string]interface{}{})
+	is.NoErr(err)
+	is.Equal(s, "<no value>")
+	_, err = TextEngine{Options: Options{Strict: true}}.Render(src, errorsDef, map[string]interface{}{})
+	is.True(err != nil)
+}
+
+func TestSnippet(t *testing.T) {
+	is := is.New(t)
+	is.Equal(snippet("one", 1, 0), "> 1 | one\n")
+	is.Equal(snippet("one", 2, 0), "")
+	is.Equal(snippet("1\n2\n3\n4\n5\n6\n7\n8\n9\n10", 9, 1), "   8 | 8\n>  9 | 9\n     | ^\n  10 | 10\n")
+}
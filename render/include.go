@@ -0,0 +1,49 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Includes describes where templates find the partials they include,
+// and the shared libraries of macros that are available to every
+// template.
+type Includes struct {
+	// Paths are the directories searched for partials, in order.
+	// If empty, partials are relative to the current directory.
+	Paths []string
+	// Libraries are partials that are loaded before every
+	// template, so the functions (plush) or templates
+	// (text/template) they define can be used.
+	Libraries []string
+}
+
+// read gets the source of the partial, looking for it in Paths.
+func (i Includes) read(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return "", errors.Wrapf(err, "partial %s", name)
+		}
+		return string(b), nil
+	}
+	paths := i.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(filepath.Join(path, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "partial %s", name)
+		}
+		return string(b), nil
+	}
+	return "", errors.Errorf("partial %s not found (searched %s)", name, strings.Join(paths, ", "))
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIncludesRead(t *testing.T) {
+	is := is.New(t)
+	team, shared := t.TempDir(), t.TempDir()
+	writeFiles(t, team, map[string]string{"objects.plush": "team"})
+	writeFiles(t, shared, map[string]string{"objects.plush": "shared", "comments.plush": "comments"})
+	includes := Includes{Paths: []string{team, shared}}
+	src, err := includes.read("objects.plush")
+	is.NoErr(err)
+	is.Equal(src, "team") // first path wins
+	src, err = includes.read("comments.plush")
+	is.NoErr(err)
+	is.Equal(src, "comments")
+	src, err = includes.read(filepath.Join(shared, "objects.plush"))
+	is.NoErr(err)
+	is.Equal(src, "shared")
+	_, err = includes.read("missing.plush")
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "partial missing.plush not found"))
+}
+
+func TestPlushPartials(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"macros.plush":  `<% let exported = fn(name) { return "Exported" + name } %>`,
+		"objects.plush": `<%= exported(prefix) %> <%= for (object) in objects { %><%= object.Name %> <%= partial("fields.plush", {"fields": object.Fields}) %>;<% } %>`,
+		"fields.plush":  `<%= for (field) in fields { %><%= camelize_down(field.Name) %>,<% } %>`,
+	})
+	def := parser.Definition{
+		PackageName: "services",
+		Objects: []parser.Object{
+			{Name: "Greeting", Fields: []parser.Field{{Name: "Text"}, {Name: "Author"}}},
+		},
+	}
+	engine := PlushEngine{Options{Includes: Includes{
+		Paths:     []string{dir},
+		Libraries: []string{"macros.plush"},
+	}}}
+	s, err := engine.Render(`<%= def.PackageName %>: <%= partial("objects.plush", {"objects": def.Objects, "prefix": params["Prefix"]}) %>`, def, map[string]interface{}{"Prefix": "_"})
+	is.NoErr(err)
+	is.Equal(s, "services: Exported_ Greeting text,author,;")
+
+	_, err = engine.Render(`<%= partial("missing.plush", {}) %>`, def, nil)
+	is.True(err != nil)
+}
+
+func TestTextIncludes(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"macros.tmpl":  `{{ define "exported" }}Exported{{ . }}{{ end }}`,
+		"objects.tmpl": `{{ range . }}{{ template "exported" .Name }} {{ include "fields.tmpl" .Fields | printf "%s" }};{{ end }}`,
+		"fields.tmpl":  `{{ range . }}{{ camelize_down .Name }},{{ end }}`,
+	})
+	def := parser.Definition{
+		PackageName: "services",
+		Objects: []parser.Object{
+			{Name: "Greeting", Fields: []parser.Field{{Name: "Text"}, {Name: "Author"}}},
+		},
+	}
+	engine := TextEngine{Options{Includes: Includes{
+		Paths:     []string{dir},
+		Libraries: []string{"macros.tmpl"},
+	}}}
+	s, err := engine.Render(`{{ .def.PackageName }}: {{ include "objects.tmpl" .def.Objects }}`, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "services: ExportedGreeting text,author,;")
+
+	_, err = engine.Render(`{{ include "missing.tmpl" . }}`, def, nil)
+	is.True(err != nil)
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+)
+
+// pathRegexp matches the placeholders in output path patterns, like
+// {{service.Name}} or {{snake_case object.Name}}.
+var pathRegexp = regexp.MustCompile(`\{\{\s*(?:(\w+)\s+)?(service|object)\.(\w+)\s*\}\}`)
+
+// Each gets what the output path pattern renders a file for: "service"
+// (like out/{{service.Name}}.gen.go), "object", or empty if the pattern
+// is a single file.
+func Each(pattern string) (string, error) {
+	var each string
+	for _, match := range pathRegexp.FindAllStringSubmatch(pattern, -1) {
+		if each != "" && each != match[2] {
+			return "", errors.Errorf("%s: use service or object, not both", pattern)
+		}
+		each = match[2]
+	}
+	return each, nil
+}
+
+// Items gets the items of the definition to render a file for: the
+// Services or Objects.
+func Items(def parser.Definition, each string) []interface{} {
+	var items []interface{}
+	switch each {
+	case "service":
+		for _, service := range def.Services {
+			items = append(items, service)
+		}
+	case "object":
+		for _, object := range def.Objects {
+			items = append(items, object)
+		}
+	}
+	return items
+}
+
+// Path renders the output path pattern for the item (a parser.Service
+// or parser.Object), replacing placeholders like {{service.Name}} with
+// its fields. Naming helpers may be used too, like
+// {{snake_case service.Name}}.
+func (o Options) Path(pattern string, item interface{}) (string, error) {
+	helpers := o.helpers()
+	v := reflect.ValueOf(item)
+	var err error
+	path := pathRegexp.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		match := pathRegexp.FindStringSubmatch(placeholder)
+		field := v.FieldByName(match[3])
+		if !field.IsValid() || field.Kind() != reflect.String {
+			err = errors.Errorf("%s: %s has no %s", placeholder, match[2], match[3])
+			return ""
+		}
+		s := field.String()
+		if match[1] != "" {
+			helper, ok := helpers[match[1]].(func(string) string)
+			if !ok {
+				err = errors.Errorf("%s: %s is not a naming helper", placeholder, match[1])
+				return ""
+			}
+			s = helper(s)
+		}
+		return s
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Glob gets a glob pattern that matches every path the output path
+// pattern renders, to find the files it rendered before.
+func Glob(pattern string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range pathRegexp.FindAllStringIndex(pattern, -1) {
+		b.WriteString(globEscape(pattern[last:match[0]]))
+		b.WriteString("*")
+		last = match[1]
+	}
+	b.WriteString(globEscape(pattern[last:]))
+	return b.String()
+}
+
+// globEscape escapes the characters that are special in glob patterns.
+// Character classes are used rather than backslashes, since on Windows
+// a backslash is the path separator.
+func globEscape(s string) string {
+	return globEscaper.Replace(s)
+}
+
+var globEscaper = func() *strings.Replacer {
+	escapes := []string{`*`, `[*]`, `?`, `[?]`, `[`, `[[]`}
+	if filepath.Separator != '\\' {
+		escapes = append(escapes, `\`, `\\`)
+	}
+	return strings.NewReplacer(escapes...)
+}()
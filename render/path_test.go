@@ -0,0 +1,71 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+)
+
+func TestEach(t *testing.T) {
+	is := is.New(t)
+	each, err := Each("out/oto.gen.go")
+	is.NoErr(err)
+	is.Equal(each, "")
+	each, err = Each("out/{{service.Name}}/{{ snake_case service.Name }}.gen.go")
+	is.NoErr(err)
+	is.Equal(each, "service")
+	each, err = Each("out/{{object.Name}}.swift")
+	is.NoErr(err)
+	is.Equal(each, "object")
+	_, err = Each("out/{{service.Name}}/{{object.Name}}.swift")
+	is.True(err != nil)
+
+	def := parser.Definition{
+		Services: []parser.Service{{Name: "GreeterService"}},
+		Objects:  []parser.Object{{Name: "GreetRequest"}, {Name: "GreetResponse"}},
+	}
+	is.Equal(Items(def, "service"), []interface{}{def.Services[0]})
+	is.Equal(Items(def, "object"), []interface{}{def.Objects[0], def.Objects[1]})
+	is.Equal(len(Items(def, "")), 0)
+}
+
+func TestPath(t *testing.T) {
+	is := is.New(t)
+	service := parser.Service{Name: "OAuthService"}
+	path, err := Options{}.Path("out/{{service.Name}}/{{ snake_case service.Name }}.gen.go", service)
+	is.NoErr(err)
+	is.Equal(path, "out/OAuthService/o_auth_service.gen.go")
+	path, err = Options{Acronyms: []string{"OAuth"}}.Path("out/{{kebab_case service.Name}}.ts", service)
+	is.NoErr(err)
+	is.Equal(path, "out/oauth-service.ts")
+	path, err = Options{}.Path("{{object.Name}}.swift", parser.Object{Name: "GreetRequest"})
+	is.NoErr(err)
+	is.Equal(path, "GreetRequest.swift")
+
+	_, err = Options{}.Path("{{service.Methods}}.go", service)
+	is.True(err != nil) // not a string
+	_, err = Options{}.Path("{{service.Nope}}.go", service)
+	is.True(err != nil)
+	_, err = Options{}.Path("{{json service.Name}}.go", service)
+	is.True(err != nil) // not a naming helper
+}
+
+func TestGlob(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Glob("out/{{service.Name}}/{{snake_case service.Name}}.gen.go"), "out/*/*.gen.go")
+	is.Equal(Glob("out[1]/{{object.Name}}?.swift"), `out[[]1]/*[?].swift`)
+	// escaped characters still match themselves
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a*[b]?", "One.gen.go")
+	err := os.MkdirAll(filepath.Dir(path), 0777)
+	is.NoErr(err)
+	err = ioutil.WriteFile(path, nil, 0666)
+	is.NoErr(err)
+	matches, err := filepath.Glob(Glob(filepath.Join(dir, "a*[b]?", "{{object.Name}}.gen.go")))
+	is.NoErr(err)
+	is.Equal(matches, []string{path})
+}
@@ -3,12 +3,13 @@ package render
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"go/doc"
 	"html/template"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/structtag"
-	"github.com/gobuffalo/plush/v4"
 	"github.com/markbates/inflect"
 	"github.com/pkg/errors"
 	"github.com/sbward/oto/parser"
@@ -16,23 +17,11 @@ import (
 
 var defaultRuleset = inflect.NewDefaultRuleset()
 
-// Render renders the template using the Definition.
+// Render renders the plush template using the Definition.
+// The Definition may come from parser.Parser.Parse, or from a saved
+// JSON definition loaded with parser.LoadDefinition.
 func Render(template string, def parser.Definition, params map[string]interface{}) (string, error) {
-	ctx := plush.NewContext()
-	ctx.Set("camelize_down", camelizeDown)
-	ctx.Set("camelize_up", camelizeUp)
-	ctx.Set("def", def)
-	ctx.Set("params", params)
-	ctx.Set("json", toJSONHelper)
-	ctx.Set("format_comment_line", formatCommentLine)
-	ctx.Set("format_comment_text", formatCommentText)
-	ctx.Set("format_comment_html", formatCommentHTML)
-	ctx.Set("format_tags", formatTags)
-	s, err := plush.Render(string(template), ctx)
-	if err != nil {
-		return "", err
-	}
-	return s, nil
+	return Plush.Render(template, def, params)
 }
 
 func toJSONHelper(v interface{}) (template.HTML, error) {
@@ -62,6 +51,17 @@ func formatCommentHTML(s string) template.HTML {
 	return template.HTML(buf.String())
 }
 
+// indent indents the non-empty lines of s with tabs.
+func indent(s interface{}, tabs int) template.HTML {
+	lines := strings.SplitAfter(fmt.Sprint(s), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = strings.Repeat("\t", tabs) + line
+		}
+	}
+	return template.HTML(strings.Join(lines, ""))
+}
+
 // formatTags formats a list of struct tag strings into one.
 // Will return an error if any of the tag strings are invalid.
 func formatTags(tags ...string) (template.HTML, error) {
@@ -82,3 +82,9 @@ func formatTags(tags ...string) (template.HTML, error) {
 	tagsStr = "`" + tagsStr + "`"
 	return template.HTML(tagsStr), nil
 }
+
+// goString formats v as a quoted Go string literal, so that any
+// text (like JSON) can be embedded in generated Go code.
+func goString(v interface{}) template.HTML {
+	return template.HTML(strconv.Quote(fmt.Sprint(v)))
+}
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"html/template"
 	"log"
 	"strings"
 	"testing"
@@ -69,7 +70,7 @@ func TestCamelizeDown(t *testing.T) {
 		"HTML":           "html",
 		"PreviewHTML":    "previewHTML",
 	} {
-		actual := camelizeDown(in)
+		actual := defaultAcronyms.camelizeDown(in)
 		if actual != expected {
 			t.Errorf("%s expected: %q but got %q", in, expected, actual)
 		}
@@ -112,3 +113,22 @@ func TestFormatCommentText(t *testing.T) {
 	is.Equal(actual, `// What about new lines?`)
 
 }
+
+func TestIndent(t *testing.T) {
+	is := is.New(t)
+	is.Equal(indent("// one\n\n// two\n", 1), template.HTML("\t// one\n\n\t// two\n"))
+	is.Equal(indent(formatCommentText("card's"), 2), template.HTML("\t\t// card's\n"))
+	is.Equal(indent("", 1), template.HTML(""))
+}
+
+func TestGoString(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(string(goString("plain")), `"plain"`)
+	is.Equal(string(goString("with `backticks` and \"quotes\"\n")), "\"with `backticks` and \\\"quotes\\\"\\n\"")
+
+	def := parser.Definition{PackageName: "services"}
+	s, err := Render(`const def = <%= go_string(json(def)) %>`, def, nil)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(s, `const def = "{\n\t\"schemaVersion\": 0,\n\t\"packageName\": \"services\",`))
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/sbward/oto/parser"
 )
 
 // Split splits the camelcase word and returns a list of words. It also
@@ -94,16 +96,49 @@ func Split(src string) (entries []string) {
 	return
 }
 
+// acronyms is a set of acronyms, keyed by their upper case form.
+type acronyms map[string]string
+
+func newAcronyms(list []string) acronyms {
+	a := make(acronyms)
+	for _, word := range list {
+		a[strings.ToUpper(word)] = word
+	}
+	return a
+}
+
+// defaultAcronyms are the parser.DefaultAcronyms.
+var defaultAcronyms = newAcronyms(parser.DefaultAcronyms)
+
+func (a acronyms) isAcronym(word string) bool {
+	_, ok := a[strings.ToUpper(word)]
+	return ok
+}
+
+// split splits the word with Split, joining any words that are
+// parts of an acronym ("O", "Auth" for OAuth).
+func (a acronyms) split(word string) []string {
+	var words []string
+	for _, word := range Split(word) {
+		if n := len(words); n > 0 && a.isAcronym(words[n-1]+word) {
+			words[n-1] += word
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
 // camelizeDown converts a name or other string into a camel case
 // version with the first letter lowercase. "ModelID" becomes "modelID".
-func camelizeDown(word string) string {
-	if isAcronym(word) {
+func (a acronyms) camelizeDown(word string) string {
+	if a.isAcronym(word) {
 		// entire word is an acronym
 		return strings.ToLower(word)
 	}
-	words := Split(word)
+	words := a.split(word)
 	for i := range words {
-		if isAcronym(words[i]) {
+		if a.isAcronym(words[i]) {
 			if i == 0 {
 				words[i] = strings.ToLower(words[i])
 			} else {
@@ -117,14 +152,14 @@ func camelizeDown(word string) string {
 
 // camelizeUp converts a name or other string into a camel case
 // version with the first letter uppercase. "modelID" becomes "ModelID".
-func camelizeUp(word string) string {
-	if isAcronym(word) {
+func (a acronyms) camelizeUp(word string) string {
+	if a.isAcronym(word) {
 		// entire word is an acronym
 		return strings.ToLower(word)
 	}
-	words := Split(word)
+	words := a.split(word)
 	for i := range words {
-		if isAcronym(words[i]) {
+		if a.isAcronym(words[i]) {
 			if i == 0 {
 				words[i] = strings.ToLower(words[i])
 			} else {
@@ -135,14 +170,3 @@ func camelizeUp(word string) string {
 	word = strings.Join(words, "")
 	return strings.ToUpper(word[:1]) + word[1:]
 }
-
-func isAcronym(word string) bool {
-	for _, ac := range baseAcronyms {
-		if strings.ToUpper(ac) == strings.ToUpper(word) {
-			return true
-		}
-	}
-	return false
-}
-
-var baseAcronyms = strings.Split(`HTML,JSON,JWT,ID,UUID,SQL,ACK,ACL,ADSL,AES,ANSI,API,ARP,ATM,BGP,BSS,CAT,CCITT,CHAP,CIDR,CIR,CLI,CPE,CPU,CRC,CRT,CSMA,CMOS,DCE,DEC,DES,DHCP,DNS,DRAM,DSL,DSLAM,DTE,DMI,EHA,EIA,EIGRP,EOF,ESS,FCC,FCS,FDDI,FTP,GBIC,gbps,GEPOF,HDLC,HTTP,HTTPS,IANA,ICMP,IDF,IDS,IEEE,IETF,IMAP,IP,IPS,ISDN,ISP,kbps,LACP,LAN,LAPB,LAPF,LLC,MAC,MAN,Mbps,MC,MDF,MIB,MoCA,MPLS,MTU,NAC,NAT,NBMA,NIC,NRZ,NRZI,NVRAM,OSI,OSPF,OUI,PAP,PAT,PC,PIM,PIM,PCM,PDU,POP3,POP,POTS,PPP,PPTP,PTT,PVST,RADIUS,RAM,RARP,RFC,RIP,RLL,ROM,RSTP,RTP,RCP,SDLC,SFD,SFP,SLARP,SLIP,SMTP,SNA,SNAP,SNMP,SOF,SRAM,SSH,SSID,STP,SYN,TDM,TFTP,TIA,TOFU,UDP,URL,URI,USB,UTP,VC,VLAN,VLSM,VPN,W3C,WAN,WEP,WiFi,WPA,WWW`, ",")
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/parser"
+)
+
+// TypeMap describes how Go types are written in another language,
+// for the type_for helper.
+type TypeMap struct {
+	// Types maps Go types to types in the language. Keys are
+	// either the full type, like time.Time or
+	// github.com/google/uuid.UUID, or the underlying type, like
+	// string, int64 or []byte. Full types are checked first.
+	Types map[string]string `json:"types" yaml:"types"`
+	// Object is the format of object (struct) types, where %s is
+	// the object name.
+	Object string `json:"object" yaml:"object"`
+	// List is the format of slices, where %s is the element type.
+	List string `json:"list" yaml:"list"`
+	// Map is the type of maps.
+	Map string `json:"map" yaml:"map"`
+	// Optional is the format of pointer types, where %s is the
+	// type. If empty, pointers are written like other types.
+	Optional string `json:"optional" yaml:"optional"`
+	// Any is the type of anything that isn't in Types.
+	Any string `json:"any" yaml:"any"`
+}
+
+// DefaultTypeMaps are the builtin TypeMaps, by language.
+var DefaultTypeMaps = map[string]TypeMap{
+	"js": {
+		Types: map[string]string{
+			"string": "string", "bool": "boolean", "[]byte": "string",
+			"int": "number", "int8": "number", "int16": "number", "int32": "number", "int64": "number",
+			"uint": "number", "uint8": "number", "uint16": "number", "uint32": "number", "uint64": "number",
+			"float32": "number", "float64": "number",
+		},
+		Object: "object",
+		List:   "%s[]",
+		Map:    "object",
+		Any:    "any",
+	},
+	"ts": {
+		Types: map[string]string{
+			"string": "string", "bool": "boolean", "[]byte": "string",
+			"int": "number", "int8": "number", "int16": "number", "int32": "number", "int64": "number",
+			"uint": "number", "uint8": "number", "uint16": "number", "uint32": "number", "uint64": "number",
+			"float32": "number", "float64": "number",
+		},
+		Object: "%s",
+		List:   "%s[]",
+		Map:    "Record<string, any>",
+		Any:    "any",
+	},
+	"swift": {
+		Types: map[string]string{
+			"string": "String", "bool": "Bool", "[]byte": "Data",
+			"int": "Int", "int8": "Int8", "int16": "Int16", "int32": "Int32", "int64": "Int64",
+			"uint": "UInt", "uint8": "UInt8", "uint16": "UInt16", "uint32": "UInt32", "uint64": "UInt64",
+			"float32": "Float", "float64": "Double",
+			"time.Time": "Date",
+		},
+		Object:   "%s",
+		List:     "[%s]",
+		Map:      "[String: Any]",
+		Optional: "%s?",
+		Any:      "Any",
+	},
+	"kotlin": {
+		Types: map[string]string{
+			"string": "String", "bool": "Boolean", "[]byte": "ByteArray",
+			"int": "Long", "int8": "Byte", "int16": "Short", "int32": "Int", "int64": "Long",
+			"uint": "ULong", "uint8": "UByte", "uint16": "UShort", "uint32": "UInt", "uint64": "ULong",
+			"float32": "Float", "float64": "Double",
+		},
+		Object:   "%s",
+		List:     "List<%s>",
+		Map:      "Map<String, Any?>",
+		Optional: "%s?",
+		Any:      "Any",
+	},
+	"dart": {
+		Types: map[string]string{
+			"string": "String", "bool": "bool", "[]byte": "String",
+			"int": "int", "int8": "int", "int16": "int", "int32": "int", "int64": "int",
+			"uint": "int", "uint8": "int", "uint16": "int", "uint32": "int", "uint64": "int",
+			"float32": "double", "float64": "double",
+			"time.Time": "DateTime",
+		},
+		Object:   "%s",
+		List:     "List<%s>",
+		Map:      "Map<String, dynamic>",
+		Optional: "%s?",
+		Any:      "dynamic",
+	},
+	"python": {
+		Types: map[string]string{
+			"string": "str", "bool": "bool", "[]byte": "str",
+			"int": "int", "int8": "int", "int16": "int", "int32": "int", "int64": "int",
+			"uint": "int", "uint8": "int", "uint16": "int", "uint32": "int", "uint64": "int",
+			"float32": "float", "float64": "float",
+		},
+		Object:   "%s",
+		List:     "List[%s]",
+		Map:      "Dict[str, Any]",
+		Optional: "Optional[%s]",
+		Any:      "Any",
+	},
+}
+
+// Merge gets the TypeMap with the non-empty fields of other, and
+// other's Types added.
+func (m TypeMap) Merge(other TypeMap) TypeMap {
+	types := make(map[string]string, len(m.Types)+len(other.Types))
+	for k, v := range m.Types {
+		types[k] = v
+	}
+	for k, v := range other.Types {
+		types[k] = v
+	}
+	m.Types = types
+	if other.Object != "" {
+		m.Object = other.Object
+	}
+	if other.List != "" {
+		m.List = other.List
+	}
+	if other.Map != "" {
+		m.Map = other.Map
+	}
+	if other.Optional != "" {
+		m.Optional = other.Optional
+	}
+	if other.Any != "" {
+		m.Any = other.Any
+	}
+	return m
+}
+
+// typeMaps gets the DefaultTypeMaps, with the Options TypeMaps merged
+// over them.
+func (o Options) typeMaps() map[string]TypeMap {
+	maps := make(map[string]TypeMap, len(DefaultTypeMaps)+len(o.TypeMaps))
+	for lang, m := range DefaultTypeMaps {
+		maps[lang] = m
+	}
+	for lang, m := range o.TypeMaps {
+		maps[lang] = maps[lang].Merge(m)
+	}
+	return maps
+}
+
+// typeFor gets the type in the language for a parser.Type.
+func typeFor(maps map[string]TypeMap, lang string, t parser.Type) (string, error) {
+	m, ok := maps[lang]
+	if !ok {
+		langs := make([]string, 0, len(maps))
+		for lang := range maps {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		return "", errors.Errorf("type_for: unknown language %q (expected one of: %s)", lang, strings.Join(langs, ", "))
+	}
+	if t.Multiple && (t.UnderlyingTypeName == "byte" || t.UnderlyingTypeName == "uint8") {
+		if typ, ok := m.Types["[]byte"]; ok {
+			return typ, nil
+		}
+	}
+	// TypeID is package.ObjectName, which includes any *
+	fullType := strings.Replace(t.TypeID, "*", "", 1)
+	typ, ok := m.Types[fullType]
+	switch {
+	case ok:
+	case t.IsObject:
+		typ = formatType(m.Object, t.CleanObjectName)
+	case t.IsMap:
+		typ = m.Map
+	default:
+		typ, ok = m.Types[t.UnderlyingTypeName]
+		if !ok {
+			typ = m.Any
+		}
+	}
+	if t.IsOptional() && m.Optional != "" {
+		typ = formatType(m.Optional, typ)
+	}
+	if t.Multiple {
+		typ = formatType(m.List, typ)
+	}
+	return typ, nil
+}
+
+// formatType replaces %s in the format with the type.
+func formatType(format, typ string) string {
+	return strings.Replace(format, "%s", typ, -1)
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/parser"
+)
+
+func TestTypeFor(t *testing.T) {
+	const pkg = "github.com/sbward/oto/testdata/services/pleasantries."
+	var (
+		stringType   = parser.Type{TypeID: pkg + "string", ObjectName: "string", CleanObjectName: "string", UnderlyingTypeName: "string"}
+		int64Type    = parser.Type{TypeID: pkg + "int64", ObjectName: "int64", CleanObjectName: "int64", UnderlyingTypeName: "int64"}
+		int64List    = parser.Type{TypeID: pkg + "int64", ObjectName: "int64", CleanObjectName: "int64", UnderlyingTypeName: "int64", Multiple: true}
+		bytesType    = parser.Type{TypeID: pkg + "uint8", ObjectName: "uint8", CleanObjectName: "uint8", UnderlyingTypeName: "uint8", Multiple: true}
+		timeType     = parser.Type{TypeID: "time.Time", ObjectName: "Time", CleanObjectName: "Time", UnderlyingTypeName: "string"}
+		optionalTime = parser.Type{TypeID: "time.*Time", ObjectName: "*Time", CleanObjectName: "Time", UnderlyingTypeName: "string"}
+		uuidType     = parser.Type{TypeID: "github.com/google/uuid.UUID", ObjectName: "UUID", CleanObjectName: "UUID", UnderlyingTypeName: "[16]byte"}
+		objectList   = parser.Type{TypeID: pkg + "Greeting", ObjectName: "Greeting", CleanObjectName: "Greeting", IsObject: true, Multiple: true}
+		optionalObj  = parser.Type{TypeID: pkg + "*Greeting", ObjectName: "*Greeting", CleanObjectName: "Greeting", IsObject: true}
+		mapType      = parser.Type{TypeID: pkg + "map[string]interface{}", ObjectName: "map[string]interface{}", UnderlyingTypeName: "map[string]interface{}", IsMap: true}
+		anyType      = parser.Type{TypeID: pkg + "interface{}", ObjectName: "interface{}", UnderlyingTypeName: "interface{}"}
+	)
+	for _, tc := range []struct {
+		lang string
+		typ  parser.Type
+		want string
+	}{
+		{"ts", stringType, "string"},
+		{"ts", int64List, "number[]"},
+		{"ts", timeType, "string"},
+		{"ts", objectList, "Greeting[]"},
+		{"ts", mapType, "Record<string, any>"},
+		{"ts", anyType, "any"},
+		{"js", optionalObj, "object"},
+		{"swift", int64Type, "Int64"},
+		{"swift", timeType, "Date"},
+		{"swift", optionalTime, "Date?"},
+		{"swift", objectList, "[Greeting]"},
+		{"swift", bytesType, "Data"},
+		{"kotlin", int64Type, "Long"},
+		{"kotlin", optionalObj, "Greeting?"},
+		{"kotlin", uuidType, "Any"},
+		{"dart", optionalTime, "DateTime?"},
+		{"python", int64List, "List[int]"},
+		{"python", optionalObj, "Optional[Greeting]"},
+	} {
+		got, err := typeFor(Options{}.typeMaps(), tc.lang, tc.typ)
+		if err != nil {
+			t.Errorf("%s %s: %s", tc.lang, tc.typ.TypeID, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: expected %q but got %q", tc.lang, tc.typ.TypeID, tc.want, got)
+		}
+	}
+
+	is := is.New(t)
+	options := Options{TypeMaps: map[string]TypeMap{
+		"kotlin": {Types: map[string]string{
+			"time.Time":                   "Instant",
+			"github.com/google/uuid.UUID": "UUID",
+		}},
+		"elm": {
+			Types:  map[string]string{"string": "String", "int64": "Int"},
+			Object: "%s",
+			List:   "List %s",
+			Any:    "Json.Value",
+		},
+	}}
+	maps := options.typeMaps()
+	typ, err := typeFor(maps, "kotlin", optionalTime)
+	is.NoErr(err)
+	is.Equal(typ, "Instant?")
+	typ, err = typeFor(maps, "kotlin", uuidType)
+	is.NoErr(err)
+	is.Equal(typ, "UUID")
+	typ, err = typeFor(maps, "kotlin", int64Type)
+	is.NoErr(err)
+	is.Equal(typ, "Long") // defaults are kept
+	typ, err = typeFor(maps, "elm", int64List)
+	is.NoErr(err)
+	is.Equal(typ, "List Int")
+	_, err = typeFor(maps, "cobol", int64List)
+	is.True(err != nil)
+	is.True(DefaultTypeMaps["kotlin"].Types["time.Time"] == "") // defaults are not changed
+}
+
+func TestTypeForHelper(t *testing.T) {
+	is := is.New(t)
+	def := parser.Definition{
+		Objects: []parser.Object{{
+			Name: "Greeting",
+			Fields: []parser.Field{
+				{Name: "Count", Type: parser.Type{UnderlyingTypeName: "int64"}},
+				{Name: "Tags", Type: parser.Type{UnderlyingTypeName: "string", Multiple: true}},
+			},
+		}},
+	}
+	s, err := Render(`<%= for (field) in def.Objects[0].Fields { %><%= field.Name %>: <%= type_for("swift", field.Type) %>
+<% } %>`, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "Count: Int64\nTags: [String]\n")
+	s, err = Text.Render(`{{ range (index .def.Objects 0).Fields }}{{ .Name }}: {{ type_for "kotlin" .Type }}
+{{ end }}`, def, nil)
+	is.NoErr(err)
+	is.Equal(s, "Count: Long\nTags: List<String>\n")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/builtin"
+)
+
+// readTemplate reads the template file, or the builtin template
+// for builtin: paths.
+func readTemplate(path string) (string, error) {
+	if builtin.IsBuiltin(path) {
+		return builtin.Read(path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "readfile")
+	}
+	return string(b), nil
+}
+
+// templateFilename gets the filename of the template, which
+// for builtin templates is the name of the embedded file.
+func templateFilename(path string) string {
+	if builtin.IsBuiltin(path) {
+		if t, err := builtin.Lookup(path); err == nil {
+			return t.Filename
+		}
+	}
+	return path
+}
+
+// listTemplates writes a table of the builtin templates.
+func listTemplates(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, t := range builtin.List() {
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\n", builtin.Prefix, t.Name, t.Filename, t.Description)
+	}
+	return tw.Flush()
+}
+
+// eject writes copies of the builtin templates into dir, so they can
+// be customized. Existing files are not overwritten.
+func eject(stdout io.Writer, names []string, dir string) error {
+	for _, name := range names {
+		t, err := builtin.Lookup(name)
+		if err != nil {
+			return err
+		}
+		src, err := builtin.Read(name)
+		if err != nil {
+			return err
+		}
+		filename := filepath.Join(dir, t.Filename)
+		if _, err := os.Stat(filename); err == nil {
+			return errors.Errorf("%s already exists", filename)
+		}
+		if _, err := writeFile(filename, []byte(src)); err != nil {
+			return errors.Wrap(err, "eject")
+		}
+		fmt.Fprintf(stdout, "%s%s -> %s\n", builtin.Prefix, t.Name, filename)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/sbward/oto/builtin"
+	"github.com/sbward/oto/parser"
+)
+
+func TestRunBuiltinTemplate(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "oto.gen.go")
+	var buf bytes.Buffer
+	err := run(&buf, []string{
+		"oto",
+		"-template", "builtin:go-server",
+		"-out", outPath,
+		"-ignore", "Ignorer",
+		"./testdata/services/pleasantries",
+	})
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "func RegisterGreeterService("))
+
+	err = run(&buf, []string{"oto", "-template", "builtin:cobol-client", "./testdata/services/pleasantries"})
+	is.True(err != nil)
+}
+
+func TestListTemplates(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-list-templates"})
+	is.NoErr(err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	is.Equal(len(lines), len(builtin.List()))
+	is.True(strings.Contains(buf.String(), "builtin:go-server"))
+}
+
+func TestEject(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-eject", "go-server,ts-client", "-outdir", dir})
+	is.NoErr(err)
+	want, err := builtin.Read("go-server")
+	is.NoErr(err)
+	got, err := ioutil.ReadFile(filepath.Join(dir, "server.go.plush"))
+	is.NoErr(err)
+	is.Equal(string(got), want)
+	_, err = ioutil.ReadFile(filepath.Join(dir, "client.ts.plush"))
+	is.NoErr(err)
+
+	err = run(&buf, []string{"oto", "-eject", "go-server", "-outdir", dir})
+	is.True(err != nil) // should not overwrite
+	is.True(strings.Contains(err.Error(), "already exists"))
+}
+
+func TestGoServerMetadata(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-dump", "json", "-ignore", "Ignorer", "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	def, err := parser.LoadDefinition(&buf)
+	is.NoErr(err)
+	greeter := def.Services[0]
+	is.Equal(greeter.Name, "GreeterService")
+	is.Equal(greeter.Methods[0].Name, "GetGreetings")
+	greeter.Methods[0].Metadata = map[string]interface{}{"auth": "admin", "rateLimit": 2.5, "rateBurst": 5, "maxInFlight": 4}
+	greeter.Methods[1].Metadata = map[string]interface{}{"maxInFlight": 1}
+	b, err := json.Marshal(def)
+	is.NoErr(err)
+	path := filepath.Join(t.TempDir(), "definition.json")
+	err = ioutil.WriteFile(path, b, 0666)
+	is.NoErr(err)
+
+	buf.Reset()
+	err = run(&buf, []string{"oto", "-template", "builtin:go-server", "-pkg", "server", "-definition", path})
+	is.NoErr(err) // output is formatted, so it is valid Go
+	s := buf.String()
+	for _, should := range []string{
+		`server.RegisterMetadata("GreeterService", "GetGreetings", "{\n\t\"auth\": \"admin\",\n\t\"maxInFlight\": 4,\n\t\"rateBurst\": 5,\n\t\"rateLimit\": 2.5\n}")`,
+		"server.Limit(\"GreeterService\", \"GetGreetings\", otohttp.Limit{\n\t\tRate:        2.5,\n\t\tBurst:       5,\n\t\tMaxInFlight: 4,\n\t})",
+		`server.RegisterMetadata("GreeterService", "Greet", "{\n\t\"maxInFlight\": 1\n}")`,
+		"server.Limit(\"GreeterService\", \"Greet\", otohttp.Limit{\n\t\tMaxInFlight: 1,\n\t})",
+	} {
+		if !strings.Contains(s, should) {
+			t.Errorf("missing: %s", should)
+		}
+	}
+	// methods without metadata register none
+	is.True(!strings.Contains(s, `server.RegisterMetadata("Welcomer"`))
+	is.True(!strings.Contains(s, `server.Limit("Welcomer"`))
+}
+
+// clientDefinition writes a definition for testing client templates,
+// returning its path. It is the pleasantries definition, with an
+// optional field, an enum and an int64 added to WelcomeRequest.
+func clientDefinition(t *testing.T) string {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-dump", "json", "-ignore", "Ignorer", "./testdata/services/pleasantries"})
+	is.NoErr(err)
+	def, err := parser.LoadDefinition(&buf)
+	is.NoErr(err)
+	object, err := def.Object("WelcomeRequest")
+	is.NoErr(err)
+	nickname := object.Fields[1]
+	nickname.Name, nickname.NameLowerCamel, nickname.NameJSON = "Nickname", "nickname", "nickname"
+	nickname.Comment = "Nickname is what to call them."
+	nickname.Type.ObjectName, nickname.Type.TypeName = "*string", "*string"
+	language := object.Fields[1]
+	language.Name, language.NameLowerCamel, language.NameJSON = "Language", "language", "language"
+	language.Comment = "Language is the language of the message."
+	language.Metadata = map[string]interface{}{"enum": []interface{}{"en", "fr"}}
+	total := object.Fields[2]
+	total.Name, total.NameLowerCamel, total.NameJSON = "Total", "total", "total"
+	total.Comment, total.Metadata = "Total is the number of welcomes so far.", nil
+	total.Type.TypeID, total.Type.TypeName, total.Type.ObjectName = "int64", "int64", "int64"
+	total.Type.UnderlyingTypeName, total.Type.CleanObjectName = "int64", "int64"
+	object.Fields = append(object.Fields, nickname, language, total)
+	b, err := json.Marshal(def)
+	is.NoErr(err)
+	path := filepath.Join(t.TempDir(), "definition.json")
+	err = ioutil.WriteFile(path, b, 0666)
+	is.NoErr(err)
+	return path
+}
+
+func TestTSClient(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-template", "builtin:ts-client", "-definition", clientDefinition(t)})
+	is.NoErr(err)
+	s := buf.String()
+	for _, should := range []string{
+		"export class Client {",
+		"\t// Greet creates a Greeting for one or more people.\n\tasync greet(greetRequest: GreetRequest, modifyHeaders?: HeadersFunc): Promise<GreetResponse> {",
+		"return this.client.call<GreetResponse>('GreeterService.Greet', greetRequest, modifyHeaders);",
+		"// WelcomeRequest is the request object for Welcomer.Welcome.\nexport interface WelcomeRequest {",
+		"\t// To is the address of the person to send the message to.\n\tto: string;",
+		"\tnames: string[];",
+		"\tnickname?: string;",
+		"\tlanguage: \"en\" | \"fr\";",
+		"\terror?: string;",
+		"\ttotal: number;",
+	} {
+		if !strings.Contains(s, should) {
+			t.Errorf("missing: %s", should)
+		}
+	}
+}
+
+func TestTSClientTypes(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-template", "builtin:ts-client", "-type", "ts:int64=bigint", "-definition", clientDefinition(t)})
+	is.NoErr(err)
+	is.True(strings.Contains(buf.String(), "\ttotal: bigint;"))
+}
+
+func TestSwiftClient(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-template", "builtin:swift-client", "-definition", clientDefinition(t)})
+	is.NoErr(err)
+	s := buf.String()
+	for _, should := range []string{
+		"\tvar to: String?\n",
+		"\tvar names: [String]?\n",
+		"\tvar times: Int?\n",
+		"\tvar total: Int64?\n",
+		"\tvar nickname: String?\n",
+		"\tvar greeting: Greeting?\n",
+		"\tvar page: Page?\n",
+	} {
+		if !strings.Contains(s, should) {
+			t.Errorf("missing: %s", should)
+		}
+	}
+}
+
+func TestGoClient(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-template", "builtin:go-client", "-pkg", "client", "-definition", clientDefinition(t)})
+	is.NoErr(err) // output is formatted, so it is valid Go
+	s := buf.String()
+	for _, should := range []string{
+		"package client\n",
+		"func New(baseURL string) *Client {",
+		"func (c *Client) call(ctx context.Context, method string, request, response interface{}) error {",
+		"// GreeterServiceClient is a client for the GreeterService.\n//\n// GreeterService is a polite API. You will love it.\ntype GreeterServiceClient struct {",
+		"func NewGreeterServiceClient(client *Client) *GreeterServiceClient {",
+		"func (s *GreeterServiceClient) Greet(ctx context.Context, request GreetRequest) (*GreetResponse, error) {",
+		`s.client.call(ctx, "GreeterService.Greet", request, &response)`,
+		"Nickname *string `json:\"nickname\"`",
+		"Error string `json:\"error,omitempty\"`",
+	} {
+		if !strings.Contains(s, should) {
+			t.Errorf("missing: %s", should)
+		}
+	}
+}
+
+func TestPythonClient(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	err := run(&buf, []string{"oto", "-template", "builtin:python-client", "-definition", clientDefinition(t)})
+	is.NoErr(err)
+	s := buf.String()
+	for _, should := range []string{
+		"import urllib.request\n",
+		"class GreeterService:\n    \"\"\"GreeterService is a polite API. You will love it.\"\"\"\n",
+		"    def greet(self, request: GreetRequest) -> GreetResponse:\n        \"\"\"Greet creates a Greeting for one or more people.\"\"\"\n",
+		`self.client.call("GreeterService.Greet", encode(request))`,
+		"    def get_greetings(self, request: GetGreetingsRequest) -> GetGreetingsResponse:",
+		"@dataclass\nclass WelcomeRequest:\n    \"\"\"WelcomeRequest is the request object for Welcomer.Welcome.\"\"\"\n",
+		"    new_customer: bool = dataclasses.field(default=False, metadata={\"json\": \"newCustomer\"})\n    \"\"\"NewCustomer indicates whether this is a new customer or not.\"\"\"\n",
+		"    nickname: Optional[str] = dataclasses.field(default=None, metadata={\"json\": \"nickname\"})\n",
+		"    names: List[str] = dataclasses.field(default_factory=list, metadata={\"json\": \"names\"})\n",
+		"    greeting: Greeting = dataclasses.field(default_factory=lambda: Greeting(), metadata={\"json\": \"greeting\"})\n",
+		"    error: str = dataclasses.field(default=\"\", metadata={\"json\": \"error\", \"omitempty\": True})\n",
+	} {
+		if !strings.Contains(s, should) {
+			t.Errorf("missing: %s", should)
+		}
+	}
+}
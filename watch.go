@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/sbward/oto/builtin"
+	"github.com/sbward/oto/parser"
+)
+
+// watchInterval is how often -watch checks for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watch renders the targets, then renders them again whenever the
+// definition or template files change, checking every interval until
+// stop is closed.
+// Parse and render errors are printed, rather than returned.
+func watch(stdout io.Writer, g generator, targets []target, cfg config, interval time.Duration, stop <-chan struct{}) error {
+	var (
+		def      parser.Definition
+		defFiles []string
+		// parsed is whether the last parse succeeded.
+		parsed bool
+		// modTimes are the modification times of the
+		// watched files at the last check.
+		modTimes = make(map[string]time.Time)
+	)
+	fmt.Fprintf(stdout, "watching for changes (ctrl+c to stop)\n")
+	for first := true; ; first = false {
+		if first || changed(modTimes, watchedFiles(defFiles)) {
+			var files []string
+			var err error
+			def, files, err = g.parse()
+			if err != nil && len(files) == 0 && first {
+				// nothing to watch
+				return errors.Wrap(err, "parse")
+			}
+			if len(files) > 0 {
+				defFiles = files
+			}
+			updateModTimes(modTimes, watchedFiles(defFiles))
+			for _, t := range targets {
+				updateModTimes(modTimes, []string{t.template})
+			}
+			updateModTimes(modTimes, g.partials(targets))
+			parsed = err == nil
+			if err != nil {
+				fmt.Fprintf(stdout, "parse: %s\n", err)
+			} else {
+				// definition changed, render everything
+				watchRender(stdout, g, targets, def, cfg, g.outPaths(targets, def))
+			}
+		} else if changed(modTimes, g.partials(targets)) {
+			// any template might use the partials
+			updateModTimes(modTimes, g.partials(targets))
+			if parsed {
+				watchRender(stdout, g, targets, def, cfg, g.outPaths(targets, def))
+			}
+		} else {
+			var changedTargets []target
+			for _, t := range targets {
+				if changed(modTimes, []string{t.template}) {
+					changedTargets = append(changedTargets, t)
+					updateModTimes(modTimes, []string{t.template})
+				}
+			}
+			if parsed && len(changedTargets) > 0 {
+				watchRender(stdout, g, changedTargets, def, cfg, g.outPaths(targets, def))
+			}
+		}
+		// outputs may be written alongside the watched files,
+		// so don't count our own changes
+		updateModTimes(modTimes, watchedFiles(defFiles))
+		updateModTimes(modTimes, g.partials(targets))
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchRender renders the targets, printing the outcome of each, and
+// runs the post-generation commands if they all succeeded. The paths
+// claimed by every target are kept. See outPaths.
+func watchRender(stdout io.Writer, g generator, targets []target, def parser.Definition, cfg config, claimed map[string]bool) {
+	ok := true
+	for _, t := range targets {
+		outputs, err := g.renderTarget(stdout, t, def, claimed)
+		if err != nil {
+			ok = false
+			fmt.Fprintf(stdout, "%s\n", err)
+			continue
+		}
+		fmt.Fprintf(stdout, "generated %s (%s)\n", t.out, humanize.Bytes(outputsSize(outputs)))
+	}
+	if !ok {
+		return
+	}
+	if err := cfg.runPost(stdout, os.Stderr); err != nil {
+		fmt.Fprintf(stdout, "%s\n", err)
+	}
+}
+
+// partials gets the files that templates may include: those in the
+// include directories and the directories of the targets' templates
+// (except the templates themselves, which are watched separately), and
+// the libraries. The directories are included too.
+func (g generator) partials(targets []target) []string {
+	dirs := append([]string{}, g.include...)
+	templates := make(map[string]bool)
+	for _, t := range targets {
+		if builtin.IsBuiltin(t.template) {
+			continue
+		}
+		dirs = append(dirs, filepath.Dir(t.template))
+		templates[filepath.Clean(t.template)] = true
+	}
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		file = filepath.Clean(file)
+		if seen[file] || templates[file] {
+			return
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				add(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	for _, library := range g.libraries {
+		// libraries may be anywhere, see render.Includes
+		if filepath.IsAbs(library) {
+			add(library)
+			continue
+		}
+		searched := dirs
+		if len(searched) == 0 {
+			searched = []string{"."}
+		}
+		for _, dir := range searched {
+			add(filepath.Join(dir, library))
+		}
+	}
+	return watchedFiles(files)
+}
+
+// watchedFiles gets the files, along with the directories that
+// contain them so that added and removed files are noticed.
+func watchedFiles(files []string) []string {
+	watched := make([]string, 0, len(files)+1)
+	dirs := make(map[string]struct{})
+	for _, file := range files {
+		watched = append(watched, file)
+		dir := filepath.Dir(file)
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		dirs[dir] = struct{}{}
+		watched = append(watched, dir)
+	}
+	return watched
+}
+
+// changed gets whether any of the files have a different
+// modification time to the one in modTimes.
+func changed(modTimes map[string]time.Time, files []string) bool {
+	for _, file := range files {
+		if !modTime(file).Equal(modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func updateModTimes(modTimes map[string]time.Time, files []string) {
+	for _, file := range files {
+		modTimes[file] = modTime(file)
+	}
+}
+
+// modTime gets the modification time of the file, or the zero
+// time if it doesn't exist.
+func modTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWatch(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= for (service) in def.Services { %><%= service.Name %>
+<% } %>`), 0666)
+	is.NoErr(err)
+	otherTemplatePath := filepath.Join(dir, "other.plush")
+	err = ioutil.WriteFile(otherTemplatePath, []byte(`other`), 0666)
+	is.NoErr(err)
+	outPath := filepath.Join(dir, "out.txt")
+	otherOutPath := filepath.Join(dir, "other.txt")
+	g := generator{
+		patterns: []string{"./testdata/services/pleasantries"},
+	}
+	targets := []target{
+		{template: templatePath, out: outPath},
+		{template: otherTemplatePath, out: otherOutPath},
+	}
+	var buf syncBuffer
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watch(&buf, g, targets, config{}, 10*time.Millisecond, stop)
+	}()
+	waitFor(t, func() bool {
+		return strings.Count(buf.String(), "generated") == 2
+	})
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "GreeterService"))
+
+	// only the changed template is rendered again
+	err = ioutil.WriteFile(templatePath, []byte(`changed`), 0666)
+	is.NoErr(err)
+	future := time.Now().Add(time.Minute)
+	err = os.Chtimes(templatePath, future, future)
+	is.NoErr(err)
+	waitFor(t, func() bool {
+		return strings.Count(buf.String(), "generated") == 3
+	})
+	b, err = ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "changed")
+	is.True(strings.HasSuffix(buf.String(), "generated "+outPath+" (7 B)\n"))
+
+	// render errors are printed, not returned
+	err = ioutil.WriteFile(templatePath, []byte(`<%= nope( %>`), 0666)
+	is.NoErr(err)
+	future = future.Add(time.Minute)
+	err = os.Chtimes(templatePath, future, future)
+	is.NoErr(err)
+	waitFor(t, func() bool {
+		return strings.Contains(buf.String(), "render: "+templatePath+":1: ")
+	})
+
+	close(stop)
+	is.NoErr(<-done)
+}
+
+func TestWatchPartials(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templateDir := filepath.Join(dir, "templates")
+	libraryDir := filepath.Join(dir, "lib")
+	is.NoErr(os.Mkdir(templateDir, 0777))
+	is.NoErr(os.Mkdir(libraryDir, 0777))
+	templatePath := filepath.Join(templateDir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= greet(partial("_name.plush")) %>`), 0666)
+	is.NoErr(err)
+	// a partial next to the template, outside -include
+	partialPath := filepath.Join(templateDir, "_name.plush")
+	err = ioutil.WriteFile(partialPath, []byte(`Mat`), 0666)
+	is.NoErr(err)
+	libraryPath := filepath.Join(libraryDir, "macros.plush")
+	err = ioutil.WriteFile(libraryPath, []byte(`<% let greet = fn(name) { return "Hi " + name } %>`), 0666)
+	is.NoErr(err)
+	outPath := filepath.Join(dir, "out.txt")
+	g := generator{
+		patterns:  []string{"./testdata/services/pleasantries"},
+		libraries: []string{libraryPath},
+	}
+	var buf syncBuffer
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watch(&buf, g, []target{{template: templatePath, out: outPath}}, config{}, 10*time.Millisecond, stop)
+	}()
+	waitFor(t, func() bool {
+		return strings.Count(buf.String(), "generated") == 1
+	})
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "Hi Mat")
+
+	future := time.Now().Add(time.Minute)
+	err = ioutil.WriteFile(partialPath, []byte(`David`), 0666)
+	is.NoErr(err)
+	err = os.Chtimes(partialPath, future, future)
+	is.NoErr(err)
+	waitFor(t, func() bool {
+		return strings.Count(buf.String(), "generated") == 2
+	})
+	b, err = ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "Hi David")
+
+	future = future.Add(time.Minute)
+	err = ioutil.WriteFile(libraryPath, []byte(`<% let greet = fn(name) { return "Hello " + name } %>`), 0666)
+	is.NoErr(err)
+	err = os.Chtimes(libraryPath, future, future)
+	is.NoErr(err)
+	waitFor(t, func() bool {
+		return strings.Count(buf.String(), "generated") == 3
+	})
+	b, err = ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "Hello David")
+
+	close(stop)
+	is.NoErr(<-done)
+}
+
+func TestWatchNothingToWatch(t *testing.T) {
+	is := is.New(t)
+	g := generator{
+		patterns: []string{"./testdata/no-such-package"},
+	}
+	var buf syncBuffer
+	err := watch(&buf, g, []target{{template: "template.plush", out: "out.txt"}}, config{}, time.Millisecond, nil)
+	is.True(err != nil)
+}
+
+func TestWatchedFiles(t *testing.T) {
+	is := is.New(t)
+	files := watchedFiles([]string{
+		filepath.Join("def", "one.go"),
+		filepath.Join("def", "two.go"),
+	})
+	is.Equal(files, []string{
+		filepath.Join("def", "one.go"),
+		"def",
+		filepath.Join("def", "two.go"),
+	})
+}
+
+// waitFor waits for fn to return true, failing the test if it takes
+// too long.
+func waitFor(t *testing.T, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// writeFile writes data to the file atomically, by writing a
+// temporary file and renaming it, so a failure never leaves a
+// partly written file.
+// The file is left alone (including its modification time) if it
+// already contains data, and missing directories are created.
+// It returns whether the file was written.
+func writeFile(filename string, data []byte) (bool, error) {
+	existing, err := ioutil.ReadFile(filename)
+	if err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	// cleans up if anything fails, otherwise it is
+	// already renamed
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(f.Name(), filename); err != nil {
+		return false, errors.Wrap(err, "rename")
+	}
+	return true, nil
+}
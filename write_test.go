@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWriteFile(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "generated", "nested", "oto.gen.go")
+
+	written, err := writeFile(filename, []byte("one"))
+	is.NoErr(err)
+	is.True(written)
+	b, err := ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.Equal(string(b), "one")
+
+	// identical content leaves the file alone
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err = os.Chtimes(filename, old, old)
+	is.NoErr(err)
+	err = os.Chmod(filename, 0600)
+	is.NoErr(err)
+	written, err = writeFile(filename, []byte("one"))
+	is.NoErr(err)
+	is.True(!written)
+	is.True(modTime(filename).Equal(old))
+
+	written, err = writeFile(filename, []byte("two"))
+	is.NoErr(err)
+	is.True(written)
+	b, err = ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.Equal(string(b), "two")
+	info, err := os.Stat(filename)
+	is.NoErr(err)
+	is.Equal(info.Mode().Perm(), os.FileMode(0600)) // mode should be kept
+
+	entries, err := ioutil.ReadDir(filepath.Dir(filename))
+	is.NoErr(err)
+	is.Equal(len(entries), 1) // temporary files should be removed
+}
+
+func TestRunRenderFailureKeepsOutput(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "template.plush")
+	err := ioutil.WriteFile(templatePath, []byte(`<%= nope() %>`), 0666)
+	is.NoErr(err)
+	outPath := filepath.Join(dir, "out.txt")
+	err = ioutil.WriteFile(outPath, []byte("previous"), 0666)
+	is.NoErr(err)
+	var buf bytes.Buffer
+	err = run(&buf, []string{"oto", "-template", templatePath, "-out", outPath, "./testdata/services/pleasantries"})
+	is.True(err != nil)
+	b, err := ioutil.ReadFile(outPath)
+	is.NoErr(err)
+	is.Equal(string(b), "previous")
+}